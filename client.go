@@ -1,11 +1,14 @@
 package work
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/garyburd/redigo/redis"
+	"math/rand"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ErrNotDeleted is returned by functions that delete jobs to indicate that although the redis commands were successful,
@@ -16,6 +19,10 @@ var ErrNotDeleted = fmt.Errorf("nothing deleted")
 // no object was actually retried by those commmands.
 var ErrNotRetried = fmt.Errorf("nothing retried")
 
+// ErrPoolNotStale is returned by RequeueInProgressJobs when the worker pool has sent a heartbeat recently, so its
+// in-progress jobs may still be being processed.
+var ErrPoolNotStale = fmt.Errorf("worker pool is not stale")
+
 // Client implements all of the functionality of the web UI. It can be used to inspect the status of a running cluster and retry dead jobs.
 type Client struct {
 	namespace string
@@ -32,14 +39,15 @@ func NewClient(namespace string, pool *redis.Pool) *Client {
 
 // WorkerPoolHeartbeat represents the heartbeat from a worker pool. WorkerPool's write a heartbeat every 5 seconds so we know they're alive and includes config information.
 type WorkerPoolHeartbeat struct {
-	WorkerPoolID string   `json:"worker_pool_id"`
-	StartedAt    int64    `json:"started_at"`
-	HeartbeatAt  int64    `json:"heartbeat_at"`
-	JobNames     []string `json:"job_names"`
-	Concurrency  uint     `json:"concurrency"`
-	Host         string   `json:"host"`
-	Pid          int      `json:"pid"`
-	WorkerIDs    []string `json:"worker_ids"`
+	WorkerPoolID  string          `json:"worker_pool_id"`
+	StartedAt     int64           `json:"started_at"`
+	HeartbeatAt   int64           `json:"heartbeat_at"`
+	JobNames      []string        `json:"job_names"`
+	JobPriorities map[string]uint `json:"job_priorities"`
+	Concurrency   uint            `json:"concurrency"`
+	Host          string          `json:"host"`
+	Pid           int             `json:"pid"`
+	WorkerIDs     []string        `json:"worker_ids"`
 }
 
 // WorkerPoolHeartbeats queries Redis and returns all WorkerPoolHeartbeat's it finds (even for those worker pools which don't have a current heartbeat).
@@ -90,6 +98,8 @@ func (c *Client) WorkerPoolHeartbeats() ([]*WorkerPoolHeartbeat, error) {
 			} else if key == "job_names" {
 				heartbeat.JobNames = strings.Split(value, ",")
 				sort.Strings(heartbeat.JobNames)
+			} else if key == "job_priorities" {
+				heartbeat.JobPriorities, err = parseJobPriorities(value)
 			} else if key == "concurrency" {
 				var vv uint64
 				vv, err = strconv.ParseUint(value, 10, 0)
@@ -116,6 +126,26 @@ func (c *Client) WorkerPoolHeartbeats() ([]*WorkerPoolHeartbeat, error) {
 	return heartbeats, nil
 }
 
+// parseJobPriorities parses the job_priorities field of a heartbeat, eg "bar:1,foo:5". Heartbeats from worker pools that predate it don't have the field.
+func parseJobPriorities(value string) (map[string]uint, error) {
+	priorities := map[string]uint{}
+	if value == "" {
+		return priorities, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		i := strings.LastIndex(pair, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid job priority %q", pair)
+		}
+		priority, err := strconv.ParseUint(pair[i+1:], 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		priorities[pair[:i]] = uint(priority)
+	}
+	return priorities, nil
+}
+
 // WorkerObservation represents the latest observation taken from a worker. The observation indicates whether the worker is busy processing a job, and if so, information about that job.
 type WorkerObservation struct {
 	WorkerID string `json:"worker_id"`
@@ -201,6 +231,251 @@ func (c *Client) WorkerObservations() ([]*WorkerObservation, error) {
 	return observations, nil
 }
 
+// ReapWorkerObservations deletes the observations of workers whose pool hasn't sent a heartbeat for as long as it takes the dead pool reaper to consider the pool dead, so that crashed workers no longer show up as busy. It returns the number of observations deleted. The pools' in-progress jobs are left for the dead pool reaper to requeue.
+func (c *Client) ReapWorkerObservations() (int64, error) {
+	hbs, err := c.WorkerPoolHeartbeats()
+	if err != nil {
+		logError("client.reap_worker_observations.worker_pool_heartbeats", err)
+		return 0, err
+	}
+
+	var keys []interface{}
+	deadBefore := nowEpochSeconds() - int64(DeadPoolTime/time.Second)
+	for _, hb := range hbs {
+		if hb.HeartbeatAt >= deadBefore {
+			continue
+		}
+		for _, wid := range hb.WorkerIDs {
+			keys = append(keys, redisKeyWorkerObservation(c.namespace, wid))
+		}
+	}
+
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	deleted, err := redis.Int64(conn.Do("DEL", keys...))
+	if err != nil {
+		logError("client.reap_worker_observations.del", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// RequeueInProgressJobs moves the jobs that the worker pool poolID has in progress back onto their queues, and returns how many it moved. It's for pools whose host died mid-processing, before the dead pool reaper gets to them or when it never will because their heartbeat has expired. Unless the pool hasn't sent a heartbeat for as long as it takes the dead pool reaper to consider it dead, it moves nothing and returns ErrPoolNotStale.
+func (c *Client) RequeueInProgressJobs(poolID string) (int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	hb, err := redis.Strings(conn.Do("HMGET", redisKeyHeartbeat(c.namespace, poolID), "heartbeat_at", "job_names"))
+	if err != nil {
+		logError("client.requeue_in_progress_jobs.hmget", err)
+		return 0, err
+	}
+	if hb[0] != "" {
+		heartbeatAt, err := strconv.ParseInt(hb[0], 10, 64)
+		if err != nil {
+			logError("client.requeue_in_progress_jobs.parse", err)
+			return 0, err
+		}
+		if heartbeatAt >= nowEpochSeconds()-int64(DeadPoolTime/time.Second) {
+			return 0, ErrPoolNotStale
+		}
+	}
+
+	// The heartbeat's job names may have expired along with it, so look in the in-progress lists of every known job too
+	jobNames, err := redis.Strings(conn.Do("SMEMBERS", redisKeyKnownJobs(c.namespace)))
+	if err != nil {
+		logError("client.requeue_in_progress_jobs.known_jobs", err)
+		return 0, err
+	}
+	if hb[1] != "" {
+		jobNames = append(jobNames, strings.Split(hb[1], ",")...)
+	}
+
+	var requeued int64
+	seen := map[string]bool{}
+	for _, jobName := range jobNames {
+		if seen[jobName] {
+			continue
+		}
+		seen[jobName] = true
+
+		for {
+			_, err := redis.Bytes(conn.Do("RPOPLPUSH", redisKeyJobsInProgress(c.namespace, poolID, jobName), redisKeyJobs(c.namespace, jobName)))
+			if err == redis.ErrNil {
+				break
+			} else if err != nil {
+				logError("client.requeue_in_progress_jobs.rpoplpush", err)
+				return requeued, err
+			}
+			requeued++
+		}
+	}
+
+	return requeued, nil
+}
+
+// StaleLock is the lock of a unique job stuck in progress in a dead worker pool. Its worker crashed before releasing the lock, so the lock keeps the same job from being enqueued again until it expires, a day after the job was enqueued.
+type StaleLock struct {
+	Key          string `json:"key"`
+	WorkerPoolID string `json:"worker_pool_id"`
+	JobName      string `json:"job_name"`
+	JobID        string `json:"job_id"`
+}
+
+// StaleLocks returns the locks of the unique jobs in progress in worker pools that haven't sent a heartbeat for as long as it takes the dead pool reaper to consider them dead, or whose heartbeat has expired. Locks of jobs in progress in live pools are never returned, and neither are locks owned by another copy of a job, such as one enqueued since, or locks taken before they recorded their owner.
+func (c *Client) StaleLocks() ([]*StaleLock, error) {
+	hbs, err := c.WorkerPoolHeartbeats()
+	if err != nil {
+		logError("client.stale_locks.worker_pool_heartbeats", err)
+		return nil, err
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	knownJobs, err := redis.Strings(conn.Do("SMEMBERS", redisKeyKnownJobs(c.namespace)))
+	if err != nil {
+		logError("client.stale_locks.known_jobs", err)
+		return nil, err
+	}
+
+	locks := []*StaleLock{}
+	deadBefore := nowEpochSeconds() - int64(DeadPoolTime/time.Second)
+	for _, hb := range hbs {
+		if hb.HeartbeatAt >= deadBefore {
+			continue
+		}
+
+		jobNames := append(append([]string{}, knownJobs...), hb.JobNames...)
+		seen := map[string]bool{}
+		for _, jobName := range jobNames {
+			if seen[jobName] {
+				continue
+			}
+			seen[jobName] = true
+
+			values, err := redis.ByteSlices(conn.Do("LRANGE", redisKeyJobsInProgress(c.namespace, hb.WorkerPoolID, jobName), 0, -1))
+			if err != nil {
+				logError("client.stale_locks.lrange", err)
+				return nil, err
+			}
+
+			for _, v := range values {
+				job, err := newJob(v, nil, nil)
+				if err != nil {
+					logError("client.stale_locks.new_job", err)
+					return nil, err
+				}
+				if !job.Unique {
+					continue
+				}
+
+				key, err := redisKeyUniqueJob(c.namespace, job.Name, job.Args)
+				if err != nil {
+					logError("client.stale_locks.key", err)
+					return nil, err
+				}
+				// The lock is released when a worker starts on its job, so it may since have been taken by another copy of
+				// the job. It's only stale if it's still owned by the job that's stuck.
+				owner, err := redis.String(conn.Do("GET", key))
+				if err == redis.ErrNil {
+					continue
+				} else if err != nil {
+					logError("client.stale_locks.get", err)
+					return nil, err
+				}
+				if owner == job.ID {
+					locks = append(locks, &StaleLock{Key: key, WorkerPoolID: hb.WorkerPoolID, JobName: job.Name, JobID: job.ID})
+				}
+			}
+		}
+	}
+
+	return locks, nil
+}
+
+// ClearStaleLocks deletes the locks returned by StaleLocks, so that their jobs can be enqueued again, and returns the number deleted. The jobs themselves stay in progress for the dead pool reaper to requeue.
+func (c *Client) ClearStaleLocks() (int64, error) {
+	locks, err := c.StaleLocks()
+	if err != nil {
+		return 0, err
+	}
+	if len(locks) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, 0, 2*len(locks))
+	for _, l := range locks {
+		args = append(args, l.Key)
+	}
+	for _, l := range locks {
+		args = append(args, l.JobID)
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	// A lock can change hands between listing and deleting it, so only delete it if its owner is unchanged
+	script := redis.NewScript(len(locks), redisLuaDeleteOwnedUniqueKeysCmd)
+	deleted, err := redis.Int64(script.Do(conn, args...))
+	if err != nil {
+		logError("client.clear_stale_locks.del", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// ReapWorkerPools removes the IDs of worker pools whose heartbeat has expired from the namespace's set of worker pools. The dead pool reaper skips pools without a heartbeat, so nothing else removes them. To be safe, a pool is only removed if it has no in-progress jobs under any known job name, so that no job is stranded. It returns the number of pools removed.
+func (c *Client) ReapWorkerPools() (int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	poolIDs, err := redis.Strings(conn.Do("SMEMBERS", redisKeyWorkerPools(c.namespace)))
+	if err != nil {
+		logError("client.reap_worker_pools.smembers", err)
+		return 0, err
+	}
+
+	jobNames, err := redis.Strings(conn.Do("SMEMBERS", redisKeyKnownJobs(c.namespace)))
+	if err != nil {
+		logError("client.reap_worker_pools.known_jobs", err)
+		return 0, err
+	}
+
+	var reaped int64
+	for _, poolID := range poolIDs {
+		keys := []interface{}{redisKeyHeartbeat(c.namespace, poolID)}
+		for _, jobName := range jobNames {
+			keys = append(keys, redisKeyJobsInProgress(c.namespace, poolID, jobName))
+		}
+
+		n, err := redis.Int64(conn.Do("EXISTS", keys...))
+		if err != nil {
+			logError("client.reap_worker_pools.exists", err)
+			return reaped, err
+		}
+		if n > 0 {
+			continue
+		}
+
+		removed, err := redis.Int64(conn.Do("SREM", redisKeyWorkerPools(c.namespace), poolID))
+		if err != nil {
+			logError("client.reap_worker_pools.srem", err)
+			return reaped, err
+		}
+		reaped += removed
+	}
+
+	return reaped, nil
+}
+
 // Queue represents a queue that holds jobs with the same name. It indicates their name, count, and latency (in seconds). Latency is a measurement of how long ago the next job to be processed was enqueued.
 type Queue struct {
 	JobName string `json:"job_name"`
@@ -278,6 +553,185 @@ func (c *Client) Queues() ([]*Queue, error) {
 	return queues, nil
 }
 
+// PeekJobs returns up to n of the jobs waiting in jobName's queue without dequeuing them, oldest first.
+func (c *Client) PeekJobs(jobName string, n int64) ([]*Job, error) {
+	if n < 1 {
+		return nil, nil
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("LRANGE", redisKeyJobs(c.namespace, jobName), -n, -1))
+	if err != nil {
+		logError("client.peek_jobs.lrange", err)
+		return nil, err
+	}
+
+	// Jobs are pushed on the left and popped from the right, so the oldest is last.
+	jobs := make([]*Job, 0, len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		job, err := newJob(values[i], nil, nil)
+		if err != nil {
+			logError("client.peek_jobs.new_job", err)
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// JobNames returns the sorted names of every job that has been enqueued in the namespace.
+func (c *Client) JobNames() ([]string, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	jobNames, err := redis.Strings(conn.Do("SMEMBERS", redisKeyKnownJobs(c.namespace)))
+	if err != nil {
+		logError("client.job_names.smembers", err)
+		return nil, err
+	}
+	sort.Strings(jobNames)
+
+	return jobNames, nil
+}
+
+// ProcessedCounts returns, by job name, how many jobs workers have taken off the queues, whatever the outcome. The counts only ever grow, so they're meant to be sampled and compared.
+func (c *Client) ProcessedCounts() (map[string]int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	counts, err := redis.Int64Map(conn.Do("HGETALL", redisKeyProcessed(c.namespace)))
+	if err != nil {
+		logError("client.processed_counts.hgetall", err)
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// FailedCounts returns, by job name, how many jobs have failed, counting each failed attempt of a retried job. Like ProcessedCounts, the counts only ever grow, so they're meant to be sampled and compared.
+func (c *Client) FailedCounts() (map[string]int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	counts, err := redis.Int64Map(conn.Do("HGETALL", redisKeyFailed(c.namespace)))
+	if err != nil {
+		logError("client.failed_counts.hgetall", err)
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// KnownJob describes a job name registered by worker pools. MaxConcurrency is the sum of the concurrency of the worker pools that can process the job; InProgress is the number of these jobs currently being processed.
+type KnownJob struct {
+	JobName        string `json:"job_name"`
+	MaxConcurrency uint   `json:"max_concurrency"`
+	InProgress     int64  `json:"in_progress"`
+}
+
+// KnownJobs returns a KnownJob for each job name registered in the namespace, derived from the worker pool heartbeats and in-progress lists.
+func (c *Client) KnownJobs() ([]*KnownJob, error) {
+	hbs, err := c.WorkerPoolHeartbeats()
+	if err != nil {
+		logError("client.known_jobs.worker_pool_heartbeats", err)
+		return nil, err
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	jobNames, err := redis.Strings(conn.Do("SMEMBERS", redisKeyKnownJobs(c.namespace)))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(jobNames)
+
+	jobs := make([]*KnownJob, 0, len(jobNames))
+	jobsByName := make(map[string]*KnownJob, len(jobNames))
+	for _, jobName := range jobNames {
+		j := &KnownJob{JobName: jobName}
+		jobs = append(jobs, j)
+		jobsByName[jobName] = j
+	}
+
+	type poolJob struct {
+		poolID string
+		job    *KnownJob
+	}
+	var poolJobs []poolJob
+
+	for _, hb := range hbs {
+		for _, jobName := range hb.JobNames {
+			j, ok := jobsByName[jobName]
+			if !ok {
+				continue
+			}
+			j.MaxConcurrency += hb.Concurrency
+			poolJobs = append(poolJobs, poolJob{poolID: hb.WorkerPoolID, job: j})
+		}
+	}
+
+	for _, pj := range poolJobs {
+		conn.Send("LLEN", redisKeyJobsInProgress(c.namespace, pj.poolID, pj.job.JobName))
+	}
+
+	if err := conn.Flush(); err != nil {
+		logError("client.known_jobs.flush", err)
+		return nil, err
+	}
+
+	for _, pj := range poolJobs {
+		n, err := redis.Int64(conn.Receive())
+		if err != nil {
+			logError("client.known_jobs.receive", err)
+			return nil, err
+		}
+		pj.job.InProgress += n
+	}
+
+	return jobs, nil
+}
+
+// RateLimit returns the namespace-wide job processing rate limit, in jobs per second. Zero means there's no limit. Workers check it each time they fetch a job, and once that many jobs have been fetched across the namespace in the current second, they wait as if their queues were empty.
+func (c *Client) RateLimit() (int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	limit, err := redis.Int64(conn.Do("GET", redisKeyRateLimit(c.namespace)))
+	if err == redis.ErrNil {
+		return 0, nil
+	} else if err != nil {
+		logError("client.rate_limit.get", err)
+		return 0, err
+	}
+	return limit, nil
+}
+
+// SetRateLimit sets the namespace-wide job processing rate limit, in jobs per second. Zero removes the limit. It takes effect from the workers' next fetch; see RateLimit.
+func (c *Client) SetRateLimit(jobsPerSecond int64) error {
+	if jobsPerSecond < 0 {
+		return fmt.Errorf("rate limit can't be negative")
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	var err error
+	if jobsPerSecond == 0 {
+		_, err = conn.Do("DEL", redisKeyRateLimit(c.namespace))
+	} else {
+		_, err = conn.Do("SET", redisKeyRateLimit(c.namespace), jobsPerSecond)
+	}
+	if err != nil {
+		logError("client.set_rate_limit", err)
+		return err
+	}
+	return nil
+}
+
 // RetryJob represents a job in the retry queue.
 type RetryJob struct {
 	RetryAt int64 `json:"retry_at"`
@@ -296,12 +750,20 @@ type DeadJob struct {
 	*Job
 }
 
+// defaultJobsPerPage is the page size of ScheduledJobs, RetryJobs, and DeadJobs.
+const defaultJobsPerPage = 20
+
 // ScheduledJobs returns a list of ScheduledJob's. The page param is 1-based; each page is 20 items. The total number of items (not pages) in the list of scheduled jobs is also returned.
 func (c *Client) ScheduledJobs(page uint) ([]*ScheduledJob, int64, error) {
+	return c.ScheduledJobsPage(page, defaultJobsPerPage)
+}
+
+// ScheduledJobsPage is like ScheduledJobs, but with perPage items per page.
+func (c *Client) ScheduledJobsPage(page, perPage uint) ([]*ScheduledJob, int64, error) {
 	key := redisKeyScheduled(c.namespace)
-	jobsWithScores, count, err := c.getZsetPage(key, page)
+	jobsWithScores, count, err := c.getZsetPage(key, page, perPage)
 	if err != nil {
-		logError("client.scheduled_jobs.get_zset_page", err)
+		logError("client.scheduled_jobs_page.get_zset_page", err)
 		return nil, 0, err
 	}
 
@@ -316,10 +778,15 @@ func (c *Client) ScheduledJobs(page uint) ([]*ScheduledJob, int64, error) {
 
 // RetryJobs returns a list of RetryJob's. The page param is 1-based; each page is 20 items. The total number of items (not pages) in the list of retry jobs is also returned.
 func (c *Client) RetryJobs(page uint) ([]*RetryJob, int64, error) {
+	return c.RetryJobsPage(page, defaultJobsPerPage)
+}
+
+// RetryJobsPage is like RetryJobs, but with perPage items per page.
+func (c *Client) RetryJobsPage(page, perPage uint) ([]*RetryJob, int64, error) {
 	key := redisKeyRetry(c.namespace)
-	jobsWithScores, count, err := c.getZsetPage(key, page)
+	jobsWithScores, count, err := c.getZsetPage(key, page, perPage)
 	if err != nil {
-		logError("client.retry_jobs.get_zset_page", err)
+		logError("client.retry_jobs_page.get_zset_page", err)
 		return nil, 0, err
 	}
 
@@ -332,12 +799,34 @@ func (c *Client) RetryJobs(page uint) ([]*RetryJob, int64, error) {
 	return jobs, count, nil
 }
 
+// AllRetryJobs returns every RetryJob, ordered by the time they'll be retried. Unlike RetryJobs it isn't paginated.
+func (c *Client) AllRetryJobs() ([]*RetryJob, error) {
+	jobsWithScores, err := c.getZsetAll(redisKeyRetry(c.namespace))
+	if err != nil {
+		logError("client.all_retry_jobs.get_zset_all", err)
+		return nil, err
+	}
+
+	jobs := make([]*RetryJob, 0, len(jobsWithScores))
+
+	for _, jws := range jobsWithScores {
+		jobs = append(jobs, &RetryJob{RetryAt: jws.Score, Job: jws.job})
+	}
+
+	return jobs, nil
+}
+
 // DeadJobs returns a list of DeadJob's. The page param is 1-based; each page is 20 items. The total number of items (not pages) in the list of dead jobs is also returned.
 func (c *Client) DeadJobs(page uint) ([]*DeadJob, int64, error) {
+	return c.DeadJobsPage(page, defaultJobsPerPage)
+}
+
+// DeadJobsPage is like DeadJobs, but with perPage items per page.
+func (c *Client) DeadJobsPage(page, perPage uint) ([]*DeadJob, int64, error) {
 	key := redisKeyDead(c.namespace)
-	jobsWithScores, count, err := c.getZsetPage(key, page)
+	jobsWithScores, count, err := c.getZsetPage(key, page, perPage)
 	if err != nil {
-		logError("client.dead_jobs.get_zset_page", err)
+		logError("client.dead_jobs_page.get_zset_page", err)
 		return nil, 0, err
 	}
 
@@ -350,28 +839,422 @@ func (c *Client) DeadJobs(page uint) ([]*DeadJob, int64, error) {
 	return jobs, count, nil
 }
 
-// DeleteDeadJob deletes a dead job from Redis.
-func (c *Client) DeleteDeadJob(diedAt int64, jobID string) error {
-	ok, _, err := c.deleteZsetJob(redisKeyDead(c.namespace), diedAt, jobID)
+// AllDeadJobs returns every DeadJob, ordered by the time they died. Unlike DeadJobs it isn't paginated, so it's meant for callers that need to filter or aggregate over the whole dead set.
+func (c *Client) AllDeadJobs() ([]*DeadJob, error) {
+	jobsWithScores, err := c.getZsetAll(redisKeyDead(c.namespace))
 	if err != nil {
-		return err
-	}
-	if !ok {
-		return ErrNotDeleted
+		logError("client.all_dead_jobs.get_zset_all", err)
+		return nil, err
 	}
-	return nil
-}
 
-// RetryDeadJob retries a dead job. The job will be re-queued on the normal work queue for eventual processing by a worker.
-func (c *Client) RetryDeadJob(diedAt int64, jobID string) error {
-	// Get queues for job names
-	queues, err := c.Queues()
-	if err != nil {
-		logError("client.retry_all_dead_jobs.queues", err)
-		return err
+	jobs := make([]*DeadJob, 0, len(jobsWithScores))
+
+	for _, jws := range jobsWithScores {
+		jobs = append(jobs, &DeadJob{DiedAt: jws.Score, Job: jws.job})
 	}
 
-	// Extract job names
+	return jobs, nil
+}
+
+// SampleDeadJobs returns a uniformly random sample of n dead jobs, along with the total number of dead jobs, so that stats over a very large dead set can be estimated without reading all of it. If there are at most n dead jobs, they're all returned.
+func (c *Client) SampleDeadJobs(n int64) ([]*DeadJob, int64, error) {
+	key := redisKeyDead(c.namespace)
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	total, err := redis.Int64(conn.Do("ZCARD", key))
+	if err != nil {
+		logError("client.sample_dead_jobs.zcard", err)
+		return nil, 0, err
+	}
+	if total <= n {
+		jobs, err := c.AllDeadJobs()
+		return jobs, total, err
+	}
+
+	// Floyd's algorithm picks n distinct ranks without materializing all of them
+	ranks := make(map[int64]bool, n)
+	for j := total - n; j < total; j++ {
+		r := rand.Int63n(j + 1)
+		if ranks[r] {
+			r = j
+		}
+		ranks[r] = true
+	}
+
+	for r := range ranks {
+		conn.Send("ZRANGE", key, r, r, "WITHSCORES")
+	}
+	if err := conn.Flush(); err != nil {
+		logError("client.sample_dead_jobs.flush", err)
+		return nil, 0, err
+	}
+
+	jobs := make([]*DeadJob, 0, n)
+	for range ranks {
+		values, err := redis.Values(conn.Receive())
+		if err != nil {
+			logError("client.sample_dead_jobs.receive", err)
+			return nil, 0, err
+		}
+		var jobsWithScores []jobScore
+		if err := redis.ScanSlice(values, &jobsWithScores); err != nil {
+			logError("client.sample_dead_jobs.scan_slice", err)
+			return nil, 0, err
+		}
+		// A job can be deleted between ZCARD and its ZRANGE, leaving nothing at its rank
+		for _, jws := range jobsWithScores {
+			job, err := newJob(jws.JobBytes, nil, nil)
+			if err != nil {
+				logError("client.sample_dead_jobs.new_job", err)
+				return nil, 0, err
+			}
+			jobs = append(jobs, &DeadJob{DiedAt: jws.Score, Job: job})
+		}
+	}
+
+	return jobs, total, nil
+}
+
+// FindDeadJob returns the dead job with the given died at time and ID, or nil if there isn't one.
+func (c *Client) FindDeadJob(diedAt int64, jobID string) (*DeadJob, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("ZRANGEBYSCORE", redisKeyDead(c.namespace), diedAt, diedAt))
+	if err != nil {
+		logError("client.find_dead_job.zrangebyscore", err)
+		return nil, err
+	}
+
+	for _, v := range values {
+		job, err := newJob(v, nil, nil)
+		if err != nil {
+			logError("client.find_dead_job.new_job", err)
+			return nil, err
+		}
+		if job.ID == jobID {
+			return &DeadJob{DiedAt: diedAt, Job: job}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// JobState describes where a job currently is in its lifecycle. State is one of "queued", "in_progress", "scheduled", "retry", or "dead". Of RunAt, RetryAt, and DiedAt, only the one matching the state is set, and WorkerPoolID is only set for in-progress jobs.
+type JobState struct {
+	State        string `json:"state"`
+	RunAt        int64  `json:"run_at,omitempty"`
+	RetryAt      int64  `json:"retry_at,omitempty"`
+	DiedAt       int64  `json:"died_at,omitempty"`
+	WorkerPoolID string `json:"worker_pool_id,omitempty"`
+	*Job
+}
+
+// FindJobState looks for the job with the given ID in the queues, the in-progress lists of live worker pools, and the scheduled, retry, and dead sets, and returns its state. It returns nil if the job isn't in any of them. This inspects every job in the namespace, so it's meant for occasional lookups.
+func (c *Client) FindJobState(jobID string) (*JobState, error) {
+	queues, err := c.Queues()
+	if err != nil {
+		logError("client.find_job_state.queues", err)
+		return nil, err
+	}
+
+	for _, q := range queues {
+		job, err := c.findListJob(redisKeyJobs(c.namespace, q.JobName), jobID)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil {
+			return &JobState{State: "queued", Job: job}, nil
+		}
+	}
+
+	hbs, err := c.WorkerPoolHeartbeats()
+	if err != nil {
+		logError("client.find_job_state.worker_pool_heartbeats", err)
+		return nil, err
+	}
+
+	for _, hb := range hbs {
+		for _, jobName := range hb.JobNames {
+			job, err := c.findListJob(redisKeyJobsInProgress(c.namespace, hb.WorkerPoolID, jobName), jobID)
+			if err != nil {
+				return nil, err
+			}
+			if job != nil {
+				return &JobState{State: "in_progress", WorkerPoolID: hb.WorkerPoolID, Job: job}, nil
+			}
+		}
+	}
+
+	zsets := []struct {
+		key   string
+		state string
+	}{
+		{redisKeyScheduled(c.namespace), "scheduled"},
+		{redisKeyRetry(c.namespace), "retry"},
+		{redisKeyDead(c.namespace), "dead"},
+	}
+
+	for _, z := range zsets {
+		jobsWithScores, err := c.getZsetAll(z.key)
+		if err != nil {
+			return nil, err
+		}
+		for _, jws := range jobsWithScores {
+			if jws.job.ID != jobID {
+				continue
+			}
+			state := &JobState{State: z.state, Job: jws.job}
+			switch z.state {
+			case "scheduled":
+				state.RunAt = jws.Score
+			case "retry":
+				state.RetryAt = jws.Score
+			case "dead":
+				state.DiedAt = jws.Score
+			}
+			return state, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindQueuedJob returns the job with the given name and ID if it's waiting in its queue, or nil if it isn't.
+func (c *Client) FindQueuedJob(jobName, jobID string) (*Job, error) {
+	return c.findListJob(redisKeyJobs(c.namespace, jobName), jobID)
+}
+
+// findListJob returns the job with the given ID in the list at key, or nil if there isn't one.
+func (c *Client) findListJob(key, jobID string) (*Job, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("LRANGE", key, 0, -1))
+	if err != nil {
+		logError("client.find_list_job.lrange", err)
+		return nil, err
+	}
+
+	for _, v := range values {
+		job, err := newJob(v, nil, nil)
+		if err != nil {
+			logError("client.find_list_job.new_job", err)
+			return nil, err
+		}
+		if job.ID == jobID {
+			return job, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// DeadJobAnnotation is a triage note an operator has attached to a dead job, eg, a status of "investigating" and a free-form note.
+type DeadJobAnnotation struct {
+	Status      string `json:"status"`
+	Note        string `json:"note,omitempty"`
+	AnnotatedAt int64  `json:"annotated_at"`
+}
+
+// AnnotateDeadJob attaches the annotation to the dead job identified by diedAt and jobID, replacing any previous annotation. The job itself isn't modified.
+func (c *Client) AnnotateDeadJob(diedAt int64, jobID string, annotation *DeadJobAnnotation) error {
+	annotation.AnnotatedAt = nowEpochSeconds()
+	b, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("HSET", redisKeyDeadAnnotations(c.namespace), redisDeadJobField(diedAt, jobID), b)
+	if err != nil {
+		logError("client.annotate_dead_job.hset", err)
+		return err
+	}
+
+	return nil
+}
+
+// AnnotateDeadJobs attaches the same annotation to each of the given dead jobs in a single round trip.
+func (c *Client) AnnotateDeadJobs(jobs []*DeadJob, annotation *DeadJobAnnotation) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	annotation.AnnotatedAt = nowEpochSeconds()
+	b, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+
+	args := make([]interface{}, 0, 2*len(jobs)+1)
+	args = append(args, redisKeyDeadAnnotations(c.namespace))
+	for _, j := range jobs {
+		args = append(args, redisDeadJobField(j.DiedAt, j.ID), b)
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("HMSET", args...); err != nil {
+		logError("client.annotate_dead_jobs.hmset", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeadJobAnnotations returns the annotations for the given dead jobs. The returned slice is parallel to jobs; jobs that haven't been annotated have a nil entry.
+func (c *Client) DeadJobAnnotations(jobs []*DeadJob) ([]*DeadJobAnnotation, error) {
+	annotations := make([]*DeadJobAnnotation, len(jobs))
+	if len(jobs) == 0 {
+		return annotations, nil
+	}
+
+	args := make([]interface{}, 0, len(jobs)+1)
+	args = append(args, redisKeyDeadAnnotations(c.namespace))
+	for _, j := range jobs {
+		args = append(args, redisDeadJobField(j.DiedAt, j.ID))
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.Values(conn.Do("HMGET", args...))
+	if err != nil {
+		logError("client.dead_job_annotations.hmget", err)
+		return nil, err
+	}
+
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		b, err := redis.Bytes(v, nil)
+		if err != nil {
+			return nil, err
+		}
+		var annotation DeadJobAnnotation
+		if err := json.Unmarshal(b, &annotation); err != nil {
+			logError("client.dead_job_annotations.unmarshal", err)
+			return nil, err
+		}
+		annotations[i] = &annotation
+	}
+
+	return annotations, nil
+}
+
+// AckDeadJob marks the dead job identified by diedAt and jobID as acknowledged, ie reviewed by an operator. The job itself isn't modified.
+func (c *Client) AckDeadJob(diedAt int64, jobID string) error {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SADD", redisKeyDeadAcked(c.namespace), redisDeadJobField(diedAt, jobID))
+	if err != nil {
+		logError("client.ack_dead_job.sadd", err)
+		return err
+	}
+
+	return nil
+}
+
+// AckDeadJobs marks each of the given dead jobs as acknowledged, like AckDeadJob.
+func (c *Client) AckDeadJobs(jobs []*DeadJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(jobs)+1)
+	args = append(args, redisKeyDeadAcked(c.namespace))
+	for _, j := range jobs {
+		args = append(args, redisDeadJobField(j.DiedAt, j.ID))
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("SADD", args...); err != nil {
+		logError("client.ack_dead_jobs.sadd", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeadJobsAcked reports whether each of the given dead jobs has been acknowledged. The returned slice is parallel to jobs.
+func (c *Client) DeadJobsAcked(jobs []*DeadJob) ([]bool, error) {
+	acked := make([]bool, len(jobs))
+	if len(jobs) == 0 {
+		return acked, nil
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	for _, j := range jobs {
+		conn.Send("SISMEMBER", redisKeyDeadAcked(c.namespace), redisDeadJobField(j.DiedAt, j.ID))
+	}
+
+	if err := conn.Flush(); err != nil {
+		logError("client.dead_jobs_acked.flush", err)
+		return nil, err
+	}
+
+	for i := range jobs {
+		isMember, err := redis.Bool(conn.Receive())
+		if err != nil {
+			logError("client.dead_jobs_acked.receive", err)
+			return nil, err
+		}
+		acked[i] = isMember
+	}
+
+	return acked, nil
+}
+
+// DeleteDeadJob deletes a dead job from Redis.
+func (c *Client) DeleteDeadJob(diedAt int64, jobID string) error {
+	ok, _, err := c.deleteZsetJob(redisKeyDead(c.namespace), diedAt, jobID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotDeleted
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	c.deleteDeadJobTriage(conn, diedAt, jobID)
+	return nil
+}
+
+// deleteDeadJobTriage removes the annotation and acknowledgement of a dead job that's no longer dead.
+func (c *Client) deleteDeadJobTriage(conn redis.Conn, diedAt int64, jobID string) {
+	field := redisDeadJobField(diedAt, jobID)
+	if _, err := conn.Do("HDEL", redisKeyDeadAnnotations(c.namespace), field); err != nil {
+		logError("client.delete_dead_job_triage.hdel", err)
+	}
+	if _, err := conn.Do("SREM", redisKeyDeadAcked(c.namespace), field); err != nil {
+		logError("client.delete_dead_job_triage.srem", err)
+	}
+}
+
+// RetryDeadJob retries a dead job. The job will be re-queued on the normal work queue for eventual processing by a worker.
+func (c *Client) RetryDeadJob(diedAt int64, jobID string) error {
+	// Get queues for job names
+	queues, err := c.Queues()
+	if err != nil {
+		logError("client.retry_all_dead_jobs.queues", err)
+		return err
+	}
+
+	// Extract job names
 	var jobNames []string
 	for _, q := range queues {
 		jobNames = append(jobNames, q.JobName)
@@ -402,10 +1285,12 @@ func (c *Client) RetryDeadJob(diedAt int64, jobID string) error {
 		return ErrNotRetried
 	}
 
+	c.deleteDeadJobTriage(conn, diedAt, jobID)
+
 	return nil
 }
 
-// RetryAllDeadJobs requeues all dead jobs. In other words, it puts them all back on the normal work queue for workers to pull from and process.
+// RetryAllDeadJobs requeues all dead jobs. In other words, it puts them all back on the normal work queue for workers to pull from and process. The jobs' annotations and acknowledgements are removed along with them.
 func (c *Client) RetryAllDeadJobs() error {
 	// Get queues for job names
 	queues, err := c.Queues()
@@ -420,12 +1305,14 @@ func (c *Client) RetryAllDeadJobs() error {
 		jobNames = append(jobNames, q.JobName)
 	}
 
-	script := redis.NewScript(len(jobNames)+1, redisLuaRequeueAllDeadCmd)
+	script := redis.NewScript(len(jobNames)+3, redisLuaRequeueAllDeadCmd)
 
-	args := make([]interface{}, 0, len(jobNames)+1+3)
-	args = append(args, redisKeyDead(c.namespace)) // KEY[1]
+	args := make([]interface{}, 0, len(jobNames)+3+3)
+	args = append(args, redisKeyDead(c.namespace))            // KEY[1]
+	args = append(args, redisKeyDeadAcked(c.namespace))       // KEY[2]
+	args = append(args, redisKeyDeadAnnotations(c.namespace)) // KEY[3]
 	for _, jobName := range jobNames {
-		args = append(args, redisKeyJobs(c.namespace, jobName)) // KEY[2, 3, ...]
+		args = append(args, redisKeyJobs(c.namespace, jobName)) // KEY[4, 5, ...]
 	}
 	args = append(args, redisKeyJobsPrefix(c.namespace)) // ARGV[1]
 	args = append(args, nowEpochSeconds())
@@ -451,11 +1338,69 @@ func (c *Client) RetryAllDeadJobs() error {
 	return nil
 }
 
+// RetryAllRetryJobs puts every job in the retry queue back on the normal work queue right away, without waiting for their backoff to elapse. Jobs are moved in batches of batchSize. Jobs whose name isn't known are moved to the dead queue. It returns the number of jobs requeued.
+func (c *Client) RetryAllRetryJobs(batchSize int) (int64, error) {
+	// Get queues for job names
+	queues, err := c.Queues()
+	if err != nil {
+		logError("client.retry_all_retry_jobs.queues", err)
+		return 0, err
+	}
+
+	// Extract job names
+	var jobNames []string
+	for _, q := range queues {
+		jobNames = append(jobNames, q.JobName)
+	}
+
+	script := redis.NewScript(len(jobNames)+2, redisLuaRequeueAllRetryCmd)
+
+	args := make([]interface{}, 0, len(jobNames)+2+3)
+	args = append(args, redisKeyRetry(c.namespace)) // KEY[1]
+	args = append(args, redisKeyDead(c.namespace))  // KEY[2]
+	for _, jobName := range jobNames {
+		args = append(args, redisKeyJobs(c.namespace, jobName)) // KEY[3, 4, ...]
+	}
+	args = append(args, redisKeyJobsPrefix(c.namespace)) // ARGV[1]
+	args = append(args, nowEpochSeconds())
+	args = append(args, batchSize)
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	var requeued int64
+	for {
+		values, err := redis.Int64s(script.Do(conn, args...))
+		if err != nil {
+			logError("client.retry_all_retry_jobs.do", err)
+			return requeued, err
+		}
+		if len(values) != 2 {
+			return requeued, fmt.Errorf("need 2 elements back from redis command")
+		}
+
+		requeued += values[1]
+		if values[0] == 0 {
+			break
+		}
+	}
+
+	return requeued, nil
+}
+
+// RetryJobsCount returns the number of jobs in the retry queue.
+func (c *Client) RetryJobsCount() (int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	return redis.Int64(conn.Do("ZCARD", redisKeyRetry(c.namespace)))
+}
+
 // DeleteAllDeadJobs deletes all dead jobs.
 func (c *Client) DeleteAllDeadJobs() error {
 	conn := c.pool.Get()
 	defer conn.Close()
-	_, err := conn.Do("DEL", redisKeyDead(c.namespace))
+	_, err := conn.Do("DEL", redisKeyDead(c.namespace), redisKeyDeadAnnotations(c.namespace), redisKeyDeadAcked(c.namespace))
 	if err != nil {
 		logError("client.delete_all_dead_jobs", err)
 		return err
@@ -464,6 +1409,119 @@ func (c *Client) DeleteAllDeadJobs() error {
 	return nil
 }
 
+// PruneDeadJobs deletes the dead jobs that died before diedBefore (an epoch timestamp), and the oldest dead jobs beyond the newest maxCount, along with their annotations and acknowledgements. A zero diedBefore or maxCount leaves out that limit. It returns the number of dead jobs deleted.
+func (c *Client) PruneDeadJobs(diedBefore, maxCount int64) (int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	key := redisKeyDead(c.namespace)
+	pruned := map[string]int64{}
+	collect := func(values []interface{}, err error) error {
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(values)-1; i += 2 {
+			member, err := redis.String(values[i], nil)
+			if err != nil {
+				return err
+			}
+			score, err := redis.Int64(values[i+1], nil)
+			if err != nil {
+				return err
+			}
+			pruned[member] = score
+		}
+		return nil
+	}
+
+	if diedBefore > 0 {
+		if err := collect(redis.Values(conn.Do("ZRANGEBYSCORE", key, "-inf", fmt.Sprintf("(%d", diedBefore), "WITHSCORES"))); err != nil {
+			logError("client.prune_dead_jobs.zrangebyscore", err)
+			return 0, err
+		}
+	}
+	if maxCount > 0 {
+		if err := collect(redis.Values(conn.Do("ZRANGE", key, 0, -(maxCount + 1), "WITHSCORES"))); err != nil {
+			logError("client.prune_dead_jobs.zrange", err)
+			return 0, err
+		}
+	}
+	if len(pruned) == 0 {
+		return 0, nil
+	}
+
+	members := redis.Args{key}
+	fields := make(redis.Args, 0, len(pruned))
+	for member, diedAt := range pruned {
+		members = members.Add(member)
+		job, err := newJob([]byte(member), nil, nil)
+		if err != nil {
+			logError("client.prune_dead_jobs.new_job", err)
+			continue
+		}
+		fields = fields.Add(redisDeadJobField(diedAt, job.ID))
+	}
+
+	n, err := redis.Int64(conn.Do("ZREM", members...))
+	if err != nil {
+		logError("client.prune_dead_jobs.zrem", err)
+		return 0, err
+	}
+	if len(fields) > 0 {
+		if _, err := conn.Do("HDEL", append(redis.Args{redisKeyDeadAnnotations(c.namespace)}, fields...)...); err != nil {
+			logError("client.prune_dead_jobs.hdel", err)
+		}
+		if _, err := conn.Do("SREM", append(redis.Args{redisKeyDeadAcked(c.namespace)}, fields...)...); err != nil {
+			logError("client.prune_dead_jobs.srem", err)
+		}
+	}
+
+	return n, nil
+}
+
+// moveQueueBatchSize is how many jobs MoveQueueJobs moves per script call, so that moving a long queue doesn't block redis for long.
+const moveQueueBatchSize = 1000
+
+// MoveQueueJobs moves all of the pending jobs in the queue of fromJobName to the end of the queue of toJobName, oldest first, and makes toJobName a known job so its queue shows up in Queues if any jobs were moved. The jobs aren't modified, so they keep their names. It returns the number of jobs moved. Jobs are moved in batches, so jobs enqueued while it runs may be moved too.
+func (c *Client) MoveQueueJobs(fromJobName, toJobName string) (int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	script := redis.NewScript(3, redisLuaMoveQueueCmd)
+	from := redisKeyJobs(c.namespace, fromJobName)
+	to := redisKeyJobs(c.namespace, toJobName)
+
+	var total int64
+	for {
+		n, err := redis.Int64(script.Do(conn, from, to, redisKeyKnownJobs(c.namespace), moveQueueBatchSize, toJobName))
+		if err != nil {
+			logError("client.move_queue_jobs.do", err)
+			return total, err
+		}
+		total += n
+		if n < moveQueueBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// MoveNamedQueueJobs moves the pending jobs named jobName from the queue of fromJobName to the end of the queue of toJobName, keeping their order, and makes toJobName a known job if any are moved. Jobs with other names stay where they are, in order. It returns the number of jobs moved. The move is atomic, so it looks through the whole queue in one go, blocking redis for as long as that takes.
+func (c *Client) MoveNamedQueueJobs(jobName, fromJobName, toJobName string) (int64, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	script := redis.NewScript(3, redisLuaMoveNamedQueueJobsCmd)
+	from := redisKeyJobs(c.namespace, fromJobName)
+	to := redisKeyJobs(c.namespace, toJobName)
+
+	n, err := redis.Int64(script.Do(conn, from, to, redisKeyKnownJobs(c.namespace), jobName, toJobName))
+	if err != nil {
+		logError("client.move_named_queue_jobs.do", err)
+		return 0, err
+	}
+	return n, nil
+}
+
 // DeleteScheduledJob deletes a job in the scheduled queue.
 func (c *Client) DeleteScheduledJob(scheduledFor int64, jobID string) error {
 	ok, jobBytes, err := c.deleteZsetJob(redisKeyScheduled(c.namespace), scheduledFor, jobID)
@@ -546,15 +1604,18 @@ type jobScore struct {
 	job      *Job
 }
 
-func (c *Client) getZsetPage(key string, page uint) ([]jobScore, int64, error) {
+func (c *Client) getZsetPage(key string, page, perPage uint) ([]jobScore, int64, error) {
 	conn := c.pool.Get()
 	defer conn.Close()
 
 	if page == 0 {
 		page = 1
 	}
+	if perPage == 0 {
+		perPage = defaultJobsPerPage
+	}
 
-	values, err := redis.Values(conn.Do("ZRANGEBYSCORE", key, "-inf", "+inf", "WITHSCORES", "LIMIT", (page-1)*20, 20))
+	values, err := redis.Values(conn.Do("ZRANGEBYSCORE", key, "-inf", "+inf", "WITHSCORES", "LIMIT", (page-1)*perPage, perPage))
 	if err != nil {
 		logError("client.get_zset_page.values", err)
 		return nil, 0, err
@@ -585,3 +1646,33 @@ func (c *Client) getZsetPage(key string, page uint) ([]jobScore, int64, error) {
 
 	return jobsWithScores, count, nil
 }
+
+func (c *Client) getZsetAll(key string) ([]jobScore, error) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.Values(conn.Do("ZRANGEBYSCORE", key, "-inf", "+inf", "WITHSCORES"))
+	if err != nil {
+		logError("client.get_zset_all.values", err)
+		return nil, err
+	}
+
+	var jobsWithScores []jobScore
+
+	if err := redis.ScanSlice(values, &jobsWithScores); err != nil {
+		logError("client.get_zset_all.scan_slice", err)
+		return nil, err
+	}
+
+	for i, jws := range jobsWithScores {
+		job, err := newJob(jws.JobBytes, nil, nil)
+		if err != nil {
+			logError("client.get_zset_all.new_job", err)
+			return nil, err
+		}
+
+		jobsWithScores[i].job = job
+	}
+
+	return jobsWithScores, nil
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/garyburd/redigo/redis"
 	"github.com/stretchr/testify/assert"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 )
@@ -182,6 +184,54 @@ func TestClientQueues(t *testing.T) {
 	assert.EqualValues(t, 0, queues[2].Latency)
 }
 
+func TestClientKnownJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	wgroup := sync.WaitGroup{}
+	wgroup.Add(1)
+	started := make(chan struct{})
+
+	watHandler := func(job *Job) error {
+		started <- struct{}{}
+		wgroup.Wait()
+		return nil
+	}
+
+	wp := NewWorkerPool(TestContext{}, 10, ns, pool)
+	wp.Job("wat", watHandler)
+	wp.Job("bob", func(job *Job) error { return nil })
+	wp.Start()
+	defer wp.Stop()
+
+	wp2 := NewWorkerPool(TestContext{}, 5, ns, pool)
+	wp2.Job("wat", watHandler)
+	wp2.Start()
+	defer wp2.Stop()
+
+	time.Sleep(20 * time.Millisecond)
+
+	enqueuer := NewEnqueuer(ns, pool)
+	_, err := enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+	<-started
+
+	client := NewClient(ns, pool)
+	jobs, err := client.KnownJobs()
+	wgroup.Done()
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(jobs)) {
+		assert.Equal(t, "bob", jobs[0].JobName)
+		assert.EqualValues(t, 10, jobs[0].MaxConcurrency)
+		assert.EqualValues(t, 0, jobs[0].InProgress)
+
+		assert.Equal(t, "wat", jobs[1].JobName)
+		assert.EqualValues(t, 15, jobs[1].MaxConcurrency)
+		assert.EqualValues(t, 1, jobs[1].InProgress)
+	}
+}
+
 func TestClientScheduledJobs(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "work"
@@ -267,6 +317,11 @@ func TestClientRetryJobs(t *testing.T) {
 		assert.EqualValues(t, 1425263429, jobs[0].Job.FailedAt)
 		assert.Equal(t, "ohno", jobs[0].LastErr)
 	}
+
+	jobs, count, err = client.RetryJobsPage(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(jobs))
+	assert.EqualValues(t, 1, count)
 }
 
 func TestClientDeadJobs(t *testing.T) {
@@ -324,6 +379,370 @@ func TestClientDeadJobs(t *testing.T) {
 	assert.EqualValues(t, 0, count)
 }
 
+func TestClientAnnotateDeadJob(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	j1 := insertDeadJob(ns, pool, "wat", 12345, 12347)
+	j2 := insertDeadJob(ns, pool, "wat", 12345, 12348)
+
+	client := NewClient(ns, pool)
+	err := client.AnnotateDeadJob(12347, j1.ID, &DeadJobAnnotation{Status: "investigating", Note: "db timeouts"})
+	assert.NoError(t, err)
+
+	jobs, err := client.AllDeadJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(jobs))
+
+	annotations, err := client.DeadJobAnnotations(jobs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(annotations))
+	if len(annotations) == 2 {
+		assert.Equal(t, "investigating", annotations[0].Status)
+		assert.Equal(t, "db timeouts", annotations[0].Note)
+		assert.EqualValues(t, 1425263409, annotations[0].AnnotatedAt)
+		assert.Nil(t, annotations[1])
+	}
+
+	// Deleting the job drops its annotation too
+	err = client.DeleteDeadJob(12347, j1.ID)
+	assert.NoError(t, err)
+	annotations, err = client.DeadJobAnnotations([]*DeadJob{{DiedAt: 12347, Job: j1}, {DiedAt: 12348, Job: j2}})
+	assert.NoError(t, err)
+	assert.Nil(t, annotations[0])
+	assert.Nil(t, annotations[1])
+}
+
+func TestClientAnnotateDeadJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertDeadJob(ns, pool, "wat", 12345, 12347)
+	insertDeadJob(ns, pool, "wat", 12345, 12348)
+
+	client := NewClient(ns, pool)
+	jobs, err := client.AllDeadJobs()
+	assert.NoError(t, err)
+
+	err = client.AnnotateDeadJobs(jobs, &DeadJobAnnotation{Status: "known_issue"})
+	assert.NoError(t, err)
+
+	annotations, err := client.DeadJobAnnotations(jobs)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(annotations)) {
+		assert.Equal(t, "known_issue", annotations[0].Status)
+		assert.Equal(t, "known_issue", annotations[1].Status)
+	}
+
+	assert.NoError(t, client.AnnotateDeadJobs(nil, &DeadJobAnnotation{Status: "known_issue"}))
+}
+
+func TestClientAckDeadJob(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	j1 := insertDeadJob(ns, pool, "wat", 12345, 12347)
+	j2 := insertDeadJob(ns, pool, "wat", 12345, 12348)
+
+	client := NewClient(ns, pool)
+	err := client.AckDeadJob(12348, j2.ID)
+	assert.NoError(t, err)
+
+	jobs, err := client.AllDeadJobs()
+	assert.NoError(t, err)
+	acked, err := client.DeadJobsAcked(jobs)
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, acked)
+
+	// Retrying the job drops its acknowledgement too
+	err = client.RetryDeadJob(12348, j2.ID)
+	assert.NoError(t, err)
+	acked, err = client.DeadJobsAcked([]*DeadJob{{DiedAt: 12347, Job: j1}, {DiedAt: 12348, Job: j2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, false}, acked)
+	err = client.AckDeadJobs([]*DeadJob{{DiedAt: 12347, Job: j1}})
+	assert.NoError(t, err)
+	acked, err = client.DeadJobsAcked([]*DeadJob{{DiedAt: 12347, Job: j1}, {DiedAt: 12348, Job: j2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, acked)
+}
+
+func TestClientJobNames(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	client := NewClient(ns, pool)
+	names, err := client.JobNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, names)
+
+	enqueuer := NewEnqueuer(ns, pool)
+	_, err = enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+	_, err = enqueuer.Enqueue("bob", nil)
+	assert.NoError(t, err)
+
+	names, err = client.JobNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "wat"}, names)
+}
+
+func TestClientProcessedCounts(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := NewEnqueuer(ns, pool)
+	_, err := enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+	_, err = enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+	_, err = enqueuer.Enqueue("bob", nil)
+	assert.NoError(t, err)
+
+	wp := NewWorkerPool(TestContext{}, 10, ns, pool)
+	wp.Job("wat", func(job *Job) error {
+		return nil
+	})
+	wp.Job("bob", func(job *Job) error {
+		return fmt.Errorf("ohno")
+	})
+	wp.Start()
+	wp.Drain()
+	wp.Stop()
+
+	client := NewClient(ns, pool)
+	counts, err := client.ProcessedCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"wat": 2, "bob": 1}, counts)
+
+	counts, err = client.FailedCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"bob": 1}, counts)
+}
+
+func TestClientReapWorkerObservations(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	conn := pool.Get()
+	// pool1 crashed a while ago, pool2 is alive
+	_, err := conn.Do("SADD", redisKeyWorkerPools(ns), "pool1", "pool2")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", redisKeyHeartbeat(ns, "pool1"), "heartbeat_at", 1425263409-3600, "worker_ids", "w1,w2,w3")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", redisKeyHeartbeat(ns, "pool2"), "heartbeat_at", 1425263409-5, "worker_ids", "w4")
+	assert.NoError(t, err)
+	for _, wid := range []string{"w1", "w2", "w4"} {
+		_, err = conn.Do("HMSET", redisKeyWorkerObservation(ns, wid), "job_name", "wat", "job_id", "job-"+wid, "started_at", 1425263000)
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	client := NewClient(ns, pool)
+	reaped, err := client.ReapWorkerObservations()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, reaped)
+
+	observations, err := client.WorkerObservations()
+	assert.NoError(t, err)
+	busy := map[string]bool{}
+	for _, ob := range observations {
+		busy[ob.WorkerID] = ob.IsBusy
+	}
+	assert.Equal(t, map[string]bool{"w1": false, "w2": false, "w3": false, "w4": true}, busy)
+
+	reaped, err = client.ReapWorkerObservations()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, reaped)
+}
+
+func TestClientRequeueInProgressJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", redisKeyKnownJobs(ns), "wat")
+	assert.NoError(t, err)
+	// crashed serves foo too, which only its heartbeat knows about
+	_, err = conn.Do("HMSET", redisKeyHeartbeat(ns, "crashed"), "heartbeat_at", 1425263409-3600, "job_names", "wat,foo")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", redisKeyHeartbeat(ns, "alive"), "heartbeat_at", 1425263409-5, "job_names", "wat")
+	assert.NoError(t, err)
+	for _, key := range []string{
+		redisKeyJobsInProgress(ns, "crashed", "wat"),
+		redisKeyJobsInProgress(ns, "crashed", "wat"),
+		redisKeyJobsInProgress(ns, "crashed", "foo"),
+		redisKeyJobsInProgress(ns, "alive", "wat"),
+		redisKeyJobsInProgress(ns, "gone", "wat"),
+	} {
+		_, err = conn.Do("LPUSH", key, `{"name":"wat","id":"a"}`)
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	client := NewClient(ns, pool)
+
+	requeued, err := client.RequeueInProgressJobs("alive")
+	assert.Equal(t, ErrPoolNotStale, err)
+	assert.EqualValues(t, 0, requeued)
+	assert.EqualValues(t, 1, listSize(pool, redisKeyJobsInProgress(ns, "alive", "wat")))
+
+	requeued, err = client.RequeueInProgressJobs("crashed")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, requeued)
+	assert.EqualValues(t, 0, listSize(pool, redisKeyJobsInProgress(ns, "crashed", "wat")))
+	assert.EqualValues(t, 0, listSize(pool, redisKeyJobsInProgress(ns, "crashed", "foo")))
+	assert.EqualValues(t, 2, listSize(pool, redisKeyJobs(ns, "wat")))
+	assert.EqualValues(t, 1, listSize(pool, redisKeyJobs(ns, "foo")))
+
+	// A pool whose heartbeat has expired is stale too
+	requeued, err = client.RequeueInProgressJobs("gone")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, requeued)
+	assert.EqualValues(t, 3, listSize(pool, redisKeyJobs(ns, "wat")))
+}
+
+func TestClientReapWorkerPools(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	// pool1 is alive, pool2's heartbeat expired, pool3's heartbeat expired but it left a job in progress
+	_, err := conn.Do("SADD", redisKeyWorkerPools(ns), "pool1", "pool2", "pool3")
+	assert.NoError(t, err)
+	_, err = conn.Do("SADD", redisKeyKnownJobs(ns), "wat", "foo")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", redisKeyHeartbeat(ns, "pool1"), "heartbeat_at", time.Now().Unix(), "job_names", "wat,foo")
+	assert.NoError(t, err)
+	_, err = conn.Do("LPUSH", redisKeyJobsInProgress(ns, "pool3", "foo"), `{"name":"foo","id":"a"}`)
+	assert.NoError(t, err)
+	conn.Close()
+
+	client := NewClient(ns, pool)
+	reaped, err := client.ReapWorkerPools()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, reaped)
+
+	conn = pool.Get()
+	poolIDs, err := redis.Strings(conn.Do("SMEMBERS", redisKeyWorkerPools(ns)))
+	assert.NoError(t, err)
+	sort.Strings(poolIDs)
+	assert.Equal(t, []string{"pool1", "pool3"}, poolIDs)
+	assert.EqualValues(t, 1, listSize(pool, redisKeyJobsInProgress(ns, "pool3", "foo")))
+	conn.Close()
+
+	reaped, err = client.ReapWorkerPools()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, reaped)
+}
+
+func TestClientFindJobState(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := NewEnqueuer(ns, pool)
+	queued, err := enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+	scheduled, err := enqueuer.EnqueueIn("wat", 100, nil)
+	assert.NoError(t, err)
+	retrying := insertRetryJob(ns, pool, "wat", 12345, nil)
+	dead := insertDeadJob(ns, pool, "wat", 12340, 12346)
+
+	// Fake a live worker pool working on a job
+	inProgress := &Job{Name: "wat", ID: makeIdentifier(), EnqueuedAt: 12300}
+	rawJSON, err := inProgress.serialize()
+	assert.NoError(t, err)
+	conn := pool.Get()
+	_, err = conn.Do("SADD", redisKeyWorkerPools(ns), "pool1")
+	assert.NoError(t, err)
+	_, err = conn.Do("HSET", redisKeyHeartbeat(ns, "pool1"), "job_names", "foo,wat")
+	assert.NoError(t, err)
+	_, err = conn.Do("LPUSH", redisKeyJobsInProgress(ns, "pool1", "wat"), rawJSON)
+	assert.NoError(t, err)
+	conn.Close()
+
+	client := NewClient(ns, pool)
+
+	state, err := client.FindJobState(queued.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, state) {
+		assert.Equal(t, "queued", state.State)
+		assert.Equal(t, queued.EnqueuedAt, state.EnqueuedAt)
+	}
+
+	state, err = client.FindJobState(inProgress.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, state) {
+		assert.Equal(t, "in_progress", state.State)
+		assert.Equal(t, "pool1", state.WorkerPoolID)
+	}
+
+	state, err = client.FindJobState(scheduled.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, state) {
+		assert.Equal(t, "scheduled", state.State)
+		assert.Equal(t, scheduled.RunAt, state.RunAt)
+	}
+
+	state, err = client.FindJobState(retrying.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, state) {
+		assert.Equal(t, "retry", state.State)
+		assert.EqualValues(t, 12345, state.RetryAt)
+	}
+
+	state, err = client.FindJobState(dead.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, state) {
+		assert.Equal(t, "dead", state.State)
+		assert.EqualValues(t, 12346, state.DiedAt)
+		assert.EqualValues(t, 12346, state.FailedAt)
+	}
+
+	state, err = client.FindJobState("nope")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestClientFindDeadJob(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	j1 := insertDeadJob(ns, pool, "wat", 12345, 12347)
+	j2 := insertDeadJob(ns, pool, "wat", 12345, 12347)
+
+	client := NewClient(ns, pool)
+	job, err := client.FindDeadJob(12347, j2.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, j2.ID, job.ID)
+		assert.EqualValues(t, 12347, job.DiedAt)
+	}
+
+	job, err = client.FindDeadJob(12348, j1.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+}
+
 func TestClientDeleteDeadJob(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "testwork"
@@ -479,6 +898,280 @@ func TestClientDeleteAllDeadJobs(t *testing.T) {
 	assert.EqualValues(t, 0, count)
 }
 
+func TestClientPruneDeadJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	job1 := insertDeadJob(ns, pool, "wat", 12345, 12346)
+	job2 := insertDeadJob(ns, pool, "wat", 12345, 12347)
+	insertDeadJob(ns, pool, "wat", 12345, 12348)
+	insertDeadJob(ns, pool, "wat", 12345, 12349)
+	insertDeadJob(ns, pool, "wat", 12345, 12350)
+
+	client := NewClient(ns, pool)
+	assert.NoError(t, client.AckDeadJob(12346, job1.ID))
+	assert.NoError(t, client.AnnotateDeadJob(12347, job2.ID, &DeadJobAnnotation{Note: "flaky"}))
+
+	deadAt := func() []int64 {
+		jobs, _, err := client.DeadJobs(1)
+		assert.NoError(t, err)
+		diedAt := []int64{}
+		for _, j := range jobs {
+			diedAt = append(diedAt, j.DiedAt)
+		}
+		return diedAt
+	}
+
+	// No limits, nothing pruned
+	n, err := client.PruneDeadJobs(0, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+
+	// Jobs that died before 12348 are pruned, with their triage
+	n, err = client.PruneDeadJobs(12348, 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.Equal(t, []int64{12348, 12349, 12350}, deadAt())
+	assert.False(t, redisInSet(pool, redisKeyDeadAcked(ns), redisDeadJobField(12346, job1.ID)))
+	assert.Equal(t, 0, len(readHash(pool, redisKeyDeadAnnotations(ns))))
+
+	// Only the newest 1 is kept
+	n, err = client.PruneDeadJobs(0, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.Equal(t, []int64{12350}, deadAt())
+}
+
+func TestClientSampleDeadJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	ids := map[string]bool{}
+	for i := int64(0); i < 20; i++ {
+		ids[insertDeadJob(ns, pool, "wat", 12345, 12346+i).ID] = true
+	}
+
+	client := NewClient(ns, pool)
+
+	jobs, total, err := client.SampleDeadJobs(5)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, total)
+	assert.Equal(t, 5, len(jobs))
+	sampled := map[string]bool{}
+	for _, j := range jobs {
+		assert.True(t, ids[j.ID])
+		assert.False(t, sampled[j.ID], "sampled twice")
+		sampled[j.ID] = true
+	}
+
+	// Asking for as many as there are returns them all
+	jobs, total, err = client.SampleDeadJobs(20)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, total)
+	assert.Equal(t, 20, len(jobs))
+}
+
+func TestClientMoveQueueJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := NewEnqueuer(ns, pool)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		job, err := enqueuer.Enqueue("wat", Q{"i": i})
+		assert.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+	_, err := enqueuer.Enqueue("wat_dlq", nil)
+	assert.NoError(t, err)
+
+	client := NewClient(ns, pool)
+	n, err := client.MoveQueueJobs("wat", "wat_dlq")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+
+	assert.EqualValues(t, 0, listSize(pool, redisKeyJobs(ns, "wat")))
+	assert.EqualValues(t, 4, listSize(pool, redisKeyJobs(ns, "wat_dlq")))
+
+	// The moved jobs are behind the one already there, in the order they were enqueued
+	jobs, err := client.PeekJobs("wat_dlq", 4)
+	assert.NoError(t, err)
+	if assert.Equal(t, 4, len(jobs)) {
+		assert.Equal(t, "wat_dlq", jobs[0].Name)
+		for i, id := range ids {
+			assert.Equal(t, id, jobs[i+1].ID)
+			assert.Equal(t, "wat", jobs[i+1].Name)
+		}
+	}
+
+	n, err = client.MoveQueueJobs("wat", "wat_dlq")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+
+	// Moving an empty queue doesn't make the destination a known job
+	n, err = client.MoveQueueJobs("wat", "wat_dlq2")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+	conn := pool.Get()
+	known, err := redis.Bool(conn.Do("SISMEMBER", redisKeyKnownJobs(ns), "wat_dlq2"))
+	conn.Close()
+	assert.NoError(t, err)
+	assert.False(t, known)
+}
+
+func TestClientMoveNamedQueueJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	// Mix foo jobs into wat's queue, between wat jobs
+	enqueuer := NewEnqueuer(ns, pool)
+	ids := map[string][]string{}
+	for _, name := range []string{"wat", "foo", "foo", "bar", "wat"} {
+		job, err := enqueuer.Enqueue(name, nil)
+		assert.NoError(t, err)
+		ids[name] = append(ids[name], job.ID)
+		if name == "foo" {
+			client := NewClient(ns, pool)
+			_, err = client.MoveQueueJobs("foo", "wat")
+			assert.NoError(t, err)
+		}
+	}
+
+	client := NewClient(ns, pool)
+	n, err := client.MoveNamedQueueJobs("foo", "wat", "bar")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+
+	peekIDs := func(jobName string) []string {
+		jobs, err := client.PeekJobs(jobName, 10)
+		assert.NoError(t, err)
+		var ids []string
+		for _, j := range jobs {
+			ids = append(ids, j.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, ids["wat"], peekIDs("wat"))
+	assert.Equal(t, append(ids["bar"], ids["foo"]...), peekIDs("bar"))
+
+	n, err = client.MoveNamedQueueJobs("foo", "wat", "bar")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+	assert.Equal(t, ids["wat"], peekIDs("wat"))
+
+	// Moving nothing doesn't make the destination a known job
+	n, err = client.MoveNamedQueueJobs("foo", "wat", "baz")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+	conn := pool.Get()
+	known, err := redis.Bool(conn.Do("SISMEMBER", redisKeyKnownJobs(ns), "baz"))
+	conn.Close()
+	assert.NoError(t, err)
+	assert.False(t, known)
+}
+
+func TestClientMoveNamedQueueJobsLongQueue(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	// More jobs than fit in a single push when the queue is rebuilt, with every third one a foo job
+	conn := pool.Get()
+	var fooIDs, watIDs []string
+	for i := 0; i < 2500; i++ {
+		job := &Job{Name: "wat", ID: makeIdentifier()}
+		if i%3 == 0 {
+			job.Name = "foo"
+			fooIDs = append(fooIDs, job.ID)
+		} else {
+			watIDs = append(watIDs, job.ID)
+		}
+		rawJSON, err := job.serialize()
+		assert.NoError(t, err)
+		_, err = conn.Do("LPUSH", redisKeyJobs(ns, "wat"), rawJSON)
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	client := NewClient(ns, pool)
+	n, err := client.MoveNamedQueueJobs("foo", "wat", "bar")
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(fooIDs), n)
+
+	peekIDs := func(jobName string) []string {
+		jobs, err := client.PeekJobs(jobName, 3000)
+		assert.NoError(t, err)
+		var ids []string
+		for _, j := range jobs {
+			ids = append(ids, j.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, watIDs, peekIDs("wat"))
+	assert.Equal(t, fooIDs, peekIDs("bar"))
+}
+
+func TestClientMoveNamedQueueJobsWhilePopping(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	fooCount := 0
+	for i := 0; i < 3000; i++ {
+		job := &Job{Name: "wat", ID: makeIdentifier()}
+		if i%2 == 0 {
+			job.Name = "foo"
+			fooCount++
+		}
+		rawJSON, err := job.serialize()
+		assert.NoError(t, err)
+		_, err = conn.Do("LPUSH", redisKeyJobs(ns, "wat"), rawJSON)
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	// A worker takes jobs off the queue while they're being moved
+	popped := make(chan int)
+	go func() {
+		conn := pool.Get()
+		defer conn.Close()
+		n := 0
+		for i := 0; i < 500; i++ {
+			rawJSON, err := redis.Bytes(conn.Do("RPOP", redisKeyJobs(ns, "wat")))
+			if err != nil {
+				break
+			}
+			job, err := newJob(rawJSON, nil, nil)
+			if err == nil && job.Name == "foo" {
+				n++
+			}
+		}
+		popped <- n
+	}()
+
+	client := NewClient(ns, pool)
+	moved, err := client.MoveNamedQueueJobs("foo", "wat", "bar")
+	assert.NoError(t, err)
+	poppedFoo := <-popped
+
+	// Every foo job was either popped or moved, and none were left behind
+	assert.EqualValues(t, fooCount, moved+int64(poppedFoo))
+	conn = pool.Get()
+	rawJSONs, err := redis.ByteSlices(conn.Do("LRANGE", redisKeyJobs(ns, "wat"), 0, -1))
+	conn.Close()
+	assert.NoError(t, err)
+	for _, rawJSON := range rawJSONs {
+		job, err := newJob(rawJSON, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "wat", job.Name)
+	}
+}
+
 func TestClientRetryAllDeadJobs(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "testwork"
@@ -497,6 +1190,8 @@ func TestClientRetryAllDeadJobs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, 4, len(jobs))
 	assert.EqualValues(t, 4, count)
+	assert.NoError(t, client.AckDeadJobs(jobs))
+	assert.NoError(t, client.AnnotateDeadJobs(jobs, &DeadJobAnnotation{Status: "investigating"}))
 
 	err = client.RetryAllDeadJobs()
 	assert.NoError(t, err)
@@ -504,6 +1199,15 @@ func TestClientRetryAllDeadJobs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, 0, count)
 
+	conn := pool.Get()
+	acked, err := redis.Int64(conn.Do("SCARD", redisKeyDeadAcked(ns)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, acked)
+	annotated, err := redis.Int64(conn.Do("HLEN", redisKeyDeadAnnotations(ns)))
+	conn.Close()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, annotated)
+
 	job := getQueuedJob(ns, pool, "wat1")
 	assert.NotNil(t, job)
 	assert.Equal(t, "wat1", job.Name)
@@ -606,6 +1310,101 @@ func TestClientRetryAllDeadJobsBig(t *testing.T) {
 	assert.Equal(t, "unknown job when requeueing", job.LastErr)
 }
 
+func TestClientRetryAllRetryJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", redisKeyKnownJobs(ns), "wat")
+	conn.Close()
+	assert.NoError(t, err)
+
+	for i := 0; i < 7; i++ {
+		insertRetryJob(ns, pool, "wat", 1425263409+1000, Q{"i": i})
+	}
+	insertRetryJob(ns, pool, "unknown", 1425263409+1000, nil)
+
+	client := NewClient(ns, pool)
+	count, err := client.RetryJobsCount()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8, count)
+
+	requeued, err := client.RetryAllRetryJobs(3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 7, requeued)
+
+	assert.EqualValues(t, 0, zsetSize(pool, redisKeyRetry(ns)))
+	assert.EqualValues(t, 7, listSize(pool, redisKeyJobs(ns, "wat")))
+	assert.EqualValues(t, 1, zsetSize(pool, redisKeyDead(ns)))
+
+	job := getQueuedJob(ns, pool, "wat")
+	if assert.NotNil(t, job) {
+		assert.EqualValues(t, 1, job.Fails)
+		assert.EqualValues(t, 1425263409, job.EnqueuedAt)
+	}
+}
+
+func TestClientPeekJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := NewEnqueuer(ns, pool)
+	for i := 0; i < 5; i++ {
+		_, err := enqueuer.Enqueue("wat", Q{"i": i})
+		assert.NoError(t, err)
+	}
+
+	client := NewClient(ns, pool)
+	jobs, err := client.PeekJobs("wat", 3)
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(jobs)) {
+		for i, j := range jobs {
+			assert.Equal(t, "wat", j.Name)
+			assert.EqualValues(t, i, j.ArgInt64("i"))
+		}
+	}
+
+	jobs, err = client.PeekJobs("wat", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(jobs))
+	assert.EqualValues(t, 5, listSize(pool, redisKeyJobs(ns, "wat")))
+
+	jobs, err = client.PeekJobs("nope", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(jobs))
+}
+
+func TestClientRateLimit(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	client := NewClient(ns, pool)
+	limit, err := client.RateLimit()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, limit)
+
+	err = client.SetRateLimit(25)
+	assert.NoError(t, err)
+	limit, err = client.RateLimit()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 25, limit)
+
+	err = client.SetRateLimit(-1)
+	assert.Error(t, err)
+
+	err = client.SetRateLimit(0)
+	assert.NoError(t, err)
+	limit, err = client.RateLimit()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, limit)
+}
+
 func TestClientDeleteScheduledJob(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "testwork"
@@ -709,6 +1508,29 @@ func insertDeadJob(ns string, pool *redis.Pool, name string, encAt, failAt int64
 	return job
 }
 
+func insertRetryJob(ns string, pool *redis.Pool, name string, retryAt int64, args map[string]interface{}) *Job {
+	job := &Job{
+		Name:       name,
+		ID:         makeIdentifier(),
+		EnqueuedAt: retryAt - 1000,
+		Args:       args,
+		Fails:      1,
+		LastErr:    "sorry",
+		FailedAt:   retryAt - 100,
+	}
+
+	rawJSON, _ := job.serialize()
+
+	conn := pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("ZADD", redisKeyRetry(ns), retryAt, rawJSON)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return job
+}
+
 func getQueuedJob(ns string, pool *redis.Pool, name string) *Job {
 	conn := pool.Get()
 	defer conn.Close()
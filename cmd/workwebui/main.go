@@ -37,7 +37,10 @@ func main() {
 	pool := newPool(*redisHostPort, database)
 
 	server := webui.NewServer(*redisNamespace, pool, *webHostPort, "admin", "admin")
-	server.Start()
+	if err := server.Start(); err != nil {
+		fmt.Printf("Error: couldn't start the server: %v\n", err)
+		return
+	}
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, os.Kill)
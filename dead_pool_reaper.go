@@ -9,10 +9,11 @@ import (
 	"github.com/garyburd/redigo/redis"
 )
 
-const (
-	deadTime   = 5 * time.Minute
-	reapPeriod = 10 * time.Minute
-)
+// DeadPoolTime is how long a worker pool can go without a heartbeat before it's considered dead, and the reaper
+// requeues its in-progress jobs.
+const DeadPoolTime = 5 * time.Minute
+
+const reapPeriod = 10 * time.Minute
 
 type deadPoolReaper struct {
 	namespace        string
@@ -149,7 +150,7 @@ func (r *deadPoolReaper) findDeadPools() (map[string][]string, error) {
 			return nil, err
 		}
 
-		if time.Unix(heartbeatAt, 0).Add(deadTime).After(time.Now()) {
+		if time.Unix(heartbeatAt, 0).Add(DeadPoolTime).After(time.Now()) {
 			continue
 		}
 
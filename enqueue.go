@@ -127,10 +127,11 @@ func (e *Enqueuer) EnqueueUnique(jobName string, args map[string]interface{}) (*
 		return nil, err
 	}
 
-	scriptArgs := make([]interface{}, 0, 3)
+	scriptArgs := make([]interface{}, 0, 4)
 	scriptArgs = append(scriptArgs, e.queuePrefix+jobName) // KEY[1]
 	scriptArgs = append(scriptArgs, uniqueKey)             // KEY[2]
 	scriptArgs = append(scriptArgs, rawJSON)               // ARGV[1]
+	scriptArgs = append(scriptArgs, job.ID)                // ARGV[2]
 
 	res, err := redis.String(e.enqueueUniqueScript.Do(conn, scriptArgs...))
 	if res == "ok" && err == nil {
@@ -171,11 +172,12 @@ func (e *Enqueuer) EnqueueUniqueIn(jobName string, secondsFromNow int64, args ma
 		Job:   job,
 	}
 
-	scriptArgs := make([]interface{}, 0, 4)
+	scriptArgs := make([]interface{}, 0, 5)
 	scriptArgs = append(scriptArgs, redisKeyScheduled(e.Namespace)) // KEY[1]
 	scriptArgs = append(scriptArgs, uniqueKey)                      // KEY[2]
 	scriptArgs = append(scriptArgs, rawJSON)                        // ARGV[1]
 	scriptArgs = append(scriptArgs, scheduledJob.RunAt)             // ARGV[2]
+	scriptArgs = append(scriptArgs, job.ID)                         // ARGV[3]
 
 	res, err := redis.String(e.enqueueUniqueInScript.Do(conn, scriptArgs...))
 
@@ -1,7 +1,7 @@
 package work
 
 import (
-	// "fmt"
+	"fmt"
 	"github.com/garyburd/redigo/redis"
 	"os"
 	"sort"
@@ -17,6 +17,7 @@ type workerPoolHeartbeater struct {
 	//
 	concurrency uint
 	jobNames    string
+	priorities  string
 	startedAt   int64
 	pid         int
 	hostname    string
@@ -45,6 +46,14 @@ func newWorkerPoolHeartbeater(namespace string, pool *redis.Pool, workerPoolID s
 	sort.Strings(jobNames)
 	h.jobNames = strings.Join(jobNames, ",")
 
+	priorities := make([]string, 0, len(jobNames))
+	for _, name := range jobNames {
+		if jt := jobTypes[name]; jt != nil {
+			priorities = append(priorities, fmt.Sprintf("%s:%d", name, jt.Priority))
+		}
+	}
+	h.priorities = strings.Join(priorities, ",")
+
 	sort.Strings(workerIDs)
 	h.workerIDs = strings.Join(workerIDs, ",")
 
@@ -96,6 +105,7 @@ func (h *workerPoolHeartbeater) heartbeat() {
 		"heartbeat_at", nowEpochSeconds(),
 		"started_at", h.startedAt,
 		"job_names", h.jobNames,
+		"job_priorities", h.priorities,
 		"concurrency", h.concurrency,
 		"worker_ids", h.workerIDs,
 		"host", h.hostname,
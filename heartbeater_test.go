@@ -17,7 +17,7 @@ func TestHeartbeater(t *testing.T) {
 	defer resetNowEpochSecondsMock()
 
 	jobTypes := map[string]*jobType{
-		"foo": nil,
+		"foo": &jobType{Name: "foo", JobOptions: JobOptions{Priority: 5}},
 		"bar": nil,
 	}
 
@@ -32,6 +32,7 @@ func TestHeartbeater(t *testing.T) {
 	assert.Equal(t, "1425263409", h["heartbeat_at"])
 	assert.Equal(t, "1425263409", h["started_at"])
 	assert.Equal(t, "bar,foo", h["job_names"])
+	assert.Equal(t, "foo:5", h["job_priorities"])
 	assert.Equal(t, "bbb,ccc", h["worker_ids"])
 	assert.Equal(t, "10", h["concurrency"])
 
@@ -22,6 +22,7 @@ type Job struct {
 	FailedAt int64  `json:"failed_at,omitempty"`
 
 	rawJSON      []byte
+	rawArgs      json.RawMessage
 	dequeuedFrom []byte
 	inProgQueue  []byte
 	argError     error
@@ -35,6 +36,15 @@ type Q map[string]interface{}
 func newJob(rawJSON, dequeuedFrom, inProgQueue []byte) (*Job, error) {
 	var job Job
 	err := json.Unmarshal(rawJSON, &job)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field == "args" {
+		// The args aren't a JSON object, eg because the job was enqueued by another library. Keep them as they are.
+		var raw struct {
+			Args json.RawMessage `json:"args"`
+		}
+		if err = json.Unmarshal(rawJSON, &raw); err == nil {
+			job.rawArgs = raw.Args
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -45,7 +55,25 @@ func newJob(rawJSON, dequeuedFrom, inProgQueue []byte) (*Job, error) {
 }
 
 func (j *Job) serialize() ([]byte, error) {
-	return json.Marshal(j)
+	if j.rawArgs == nil {
+		return json.Marshal(j)
+	}
+
+	type job Job
+	return json.Marshal(struct {
+		*job
+		Args json.RawMessage `json:"args"`
+	}{(*job)(j), j.rawArgs})
+}
+
+// ArgsJSON returns the job's arguments as JSON. Jobs are normally enqueued with a JSON object of arguments, which is
+// also available in Args. Jobs enqueued by other libraries might have an array or a scalar instead; Args is nil for
+// those, but ArgsJSON returns them unchanged.
+func (j *Job) ArgsJSON() (json.RawMessage, error) {
+	if j.rawArgs != nil {
+		return j.rawArgs, nil
+	}
+	return json.Marshal(j.Args)
 }
 
 // setArg sets a single named argument on the job.
@@ -253,3 +253,46 @@ func TestJobArgumentExtractionBadFloat(t *testing.T) {
 		j.argError = nil
 	}
 }
+
+func TestJobNonObjectArgs(t *testing.T) {
+	testCases := []string{
+		`[1,"two",{"three":3}]`,
+		`"hello"`,
+		`42`,
+		`true`,
+	}
+
+	for _, args := range testCases {
+		j, err := newJob([]byte(`{"name":"wat","id":"abc","t":1425263409,"args":`+args+`,"fails":2}`), nil, nil)
+		if assert.NoError(t, err, args) {
+			assert.Equal(t, "wat", j.Name)
+			assert.EqualValues(t, 2, j.Fails)
+			assert.Nil(t, j.Args)
+
+			argsJSON, err := j.ArgsJSON()
+			assert.NoError(t, err)
+			assert.Equal(t, args, string(argsJSON))
+
+			// Retrying or killing the job must not lose its args
+			j.Fails++
+			b, err := j.serialize()
+			assert.NoError(t, err)
+			j2, err := newJob(b, nil, nil)
+			assert.NoError(t, err)
+			assert.EqualValues(t, 3, j2.Fails)
+			argsJSON, err = j2.ArgsJSON()
+			assert.NoError(t, err)
+			assert.Equal(t, args, string(argsJSON))
+		}
+	}
+
+	j, err := newJob([]byte(`{"name":"wat","id":"abc","t":1425263409,"args":{"a":1}}`), nil, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, j.ArgInt64("a"))
+	argsJSON, err := j.ArgsJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(argsJSON))
+
+	_, err = newJob([]byte(`{"name":"wat","id":"abc","t":"yesterday","args":{}}`), nil, nil)
+	assert.Error(t, err)
+}
@@ -40,6 +40,18 @@ func redisKeyDead(namespace string) string {
 	return redisNamespacePrefix(namespace) + "dead"
 }
 
+func redisKeyDeadAnnotations(namespace string) string {
+	return redisNamespacePrefix(namespace) + "dead_annotations"
+}
+
+func redisKeyDeadAcked(namespace string) string {
+	return redisNamespacePrefix(namespace) + "dead_acked"
+}
+
+func redisDeadJobField(diedAt int64, jobID string) string {
+	return fmt.Sprintf("%d:%s", diedAt, jobID)
+}
+
 func redisKeyScheduled(namespace string) string {
 	return redisNamespacePrefix(namespace) + "scheduled"
 }
@@ -78,6 +90,25 @@ func redisKeyLastPeriodicEnqueue(namespace string) string {
 	return redisNamespacePrefix(namespace) + "last_periodic_enqueue"
 }
 
+// a hash of job name to the number of jobs of that name workers have dequeued
+func redisKeyProcessed(namespace string) string {
+	return redisNamespacePrefix(namespace) + "processed"
+}
+
+func redisKeyFailed(namespace string) string {
+	return redisNamespacePrefix(namespace) + "failed"
+}
+
+func redisKeyRateLimit(namespace string) string {
+	return redisNamespacePrefix(namespace) + "rate_limit"
+}
+
+// redisKeyRateLimitCount is the key of the count of jobs fetched in the second starting at epochSeconds, which the rate
+// limit is checked against.
+func redisKeyRateLimitCount(namespace string, epochSeconds int64) string {
+	return fmt.Sprintf("%srate_limit_count:%d", redisNamespacePrefix(namespace), epochSeconds)
+}
+
 // KEYS[1] = the 1st job queue we want to try, eg, "work:jobs:emails"
 // KEYS[2] = the 1st job queue's in prog queue, eg, "work:jobs:emails:97c84119d13cb54119a38743:inprogress"
 // KEYS[3] = the 2nd job queue...
@@ -97,6 +128,85 @@ end
 return nil
 `
 
+// KEYS[1] = hash of processed counts by job name, eg "work:processed"
+// KEYS[2] = the namespace-wide rate limit in jobs per second, eg "work:rate_limit". Unset or zero means no limit.
+// KEYS[3] = the count of jobs fetched in the current second, eg "work:rate_limit_count:1425263409"
+// KEYS[4] = the 1st job queue we want to try, eg, "work:jobs:emails"
+// KEYS[5] = the 1st job queue's in prog queue, eg, "work:jobs:emails:97c84119d13cb54119a38743:inprogress"
+// ...
+// KEYS[N] = the last job queue...
+// KEYS[N+1] = the last job queue's in prog queue...
+// ARGV[1] = jobs prefix, eg, "work:jobs:". The rest of the job queue's key is the job name to count the job under.
+// Returns: like redisLuaRpoplpushMultiCmd, counting the job it fetches as processed. Once the rate limit's worth of
+// jobs have been fetched in the current second, it returns nil without fetching.
+var redisLuaFetchJobCmd = `
+local limit = tonumber(redis.call('get', KEYS[2]))
+if limit and limit > 0 and tonumber(redis.call('get', KEYS[3]) or 0) >= limit then
+  return nil
+end
+local res
+local keylen = #KEYS
+for i=4,keylen,2 do
+  res = redis.call('rpoplpush', KEYS[i], KEYS[i+1])
+  if res then
+    redis.call('hincrby', KEYS[1], string.sub(KEYS[i], #ARGV[1] + 1), 1)
+    if limit and limit > 0 then
+      redis.call('incr', KEYS[3])
+      redis.call('expire', KEYS[3], 2)
+    end
+    return {res, KEYS[i], KEYS[i+1]}
+  end
+end
+return nil
+`
+
+// KEYS[1] = the job queue to move jobs from, eg "work:jobs:emails"
+// KEYS[2] = the job queue to move them to, eg "work:jobs:emails_deadletter"
+// KEYS[3] = the set of known jobs, eg "work:known_jobs"
+// ARGV[1] = the most jobs to move
+// ARGV[2] = the job name of the queue they're moved to, eg "emails_deadletter". It's made a known job if any jobs are moved.
+var redisLuaMoveQueueCmd = `
+local n = 0
+while n < tonumber(ARGV[1]) and redis.call('rpoplpush', KEYS[1], KEYS[2]) do
+  n = n + 1
+end
+if n > 0 then
+  redis.call('sadd', KEYS[3], ARGV[2])
+end
+return n
+`
+
+// KEYS[1] = the job queue to move jobs from, eg "work:jobs:emails"
+// KEYS[2] = the job queue to move them to, eg "work:jobs:mailer"
+// KEYS[3] = the set of known jobs, eg "work:known_jobs"
+// ARGV[1] = the name of the jobs to move, eg "emails"
+// ARGV[2] = the job name of the queue they're moved to, eg "mailer". It's made a known job if any jobs are moved.
+// Returns: the number of jobs moved
+var redisLuaMoveNamedQueueJobsCmd = `
+local jobs = redis.call('lrange', KEYS[1], 0, -1)
+local kept, moved = {}, {}
+for i = 1, #jobs do
+  if cjson.decode(jobs[i])['name'] == ARGV[1] then
+    moved[#moved + 1] = jobs[i]
+  else
+    kept[#kept + 1] = jobs[i]
+  end
+end
+if #moved == 0 then
+  return 0
+end
+-- Rebuild the from queue with the jobs that stay, a chunk at a time to keep within unpack's limit
+redis.call('del', KEYS[1])
+for i = 1, #kept, 1000 do
+  redis.call('rpush', KEYS[1], unpack(kept, i, math.min(i + 999, #kept)))
+end
+for i = #moved, 1, -1 do
+  redis.call('lpush', KEYS[2], moved[i])
+end
+redis.call('sadd', KEYS[3], ARGV[2])
+return #moved
+`
+
 // KEYS[1] = zset of jobs (retry or scheduled), eg work:retry
 // KEYS[2] = zset of dead, eg work:dead. If we don't know the jobName of a job, we'll put it in dead.
 // KEYS[3...] = known job queues, eg ["work:jobs:create_watch", "work:jobs:send_email", ...]
@@ -188,23 +298,28 @@ return requeuedCount
 `
 
 // KEYS[1] = zset of dead jobs, eg work:dead
-// KEYS[2...] = known job queues, eg ["work:jobs:create_watch", "work:jobs:send_email", ...]
+// KEYS[2] = set of acknowledged dead jobs, eg work:dead_acked
+// KEYS[3] = hash of dead job annotations, eg work:dead_annotations
+// KEYS[4...] = known job queues, eg ["work:jobs:create_watch", "work:jobs:send_email", ...]
 // ARGV[1] = jobs prefix, eg, "work:jobs:". We'll take that and append the job name from the JSON object in order to queue up a job
 // ARGV[2] = current time in epoch seconds
 // ARGV[3] = max number of jobs to requeue
 // Returns: number of jobs requeued
 var redisLuaRequeueAllDeadCmd = `
-local jobs, i, j, queue, found, requeuedCount
-jobs = redis.call('zrangebyscore', KEYS[1], '-inf', ARGV[2], 'LIMIT', 0, ARGV[3])
+local jobs, i, j, queue, found, requeuedCount, field
+jobs = redis.call('zrangebyscore', KEYS[1], '-inf', ARGV[2], 'WITHSCORES', 'LIMIT', 0, ARGV[3])
 local jobCount = #jobs
 requeuedCount = 0
-for i=1,jobCount do
+for i=1,jobCount,2 do
   j = cjson.decode(jobs[i])
   redis.call('zrem', KEYS[1], jobs[i])
+  field = jobs[i+1] .. ':' .. j['id']
+  redis.call('srem', KEYS[2], field)
+  redis.call('hdel', KEYS[3], field)
   queue = ARGV[1] .. j['name']
   found = false
-  for _,v in pairs(KEYS) do
-    if v == queue then
+  for k=4,#KEYS do
+    if KEYS[k] == queue then
       j['t'] = tonumber(ARGV[2])
       j['fails'] = nil
       j['failed_at'] = nil
@@ -224,11 +339,47 @@ end
 return requeuedCount
 `
 
+// KEYS[1] = zset of retry jobs, eg work:retry
+// KEYS[2] = zset of dead jobs, eg work:dead. If we don't know the jobName of a job, we'll put it in dead.
+// KEYS[3...] = known job queues, eg ["work:jobs:create_watch", "work:jobs:send_email", ...]
+// ARGV[1] = jobs prefix, eg, "work:jobs:". We'll take that and append the job name from the JSON object in order to queue up a job
+// ARGV[2] = current time in epoch seconds
+// ARGV[3] = max number of jobs to requeue
+// Returns: {number of jobs taken off the retry queue, number of jobs requeued}
+var redisLuaRequeueAllRetryCmd = `
+local jobs, i, j, queue, found, requeuedCount
+jobs = redis.call('zrange', KEYS[1], 0, ARGV[3] - 1)
+local jobCount = #jobs
+requeuedCount = 0
+for i=1,jobCount do
+  j = cjson.decode(jobs[i])
+  redis.call('zrem', KEYS[1], jobs[i])
+  queue = ARGV[1] .. j['name']
+  found = false
+  for _,v in pairs(KEYS) do
+    if v == queue then
+      j['t'] = tonumber(ARGV[2])
+      redis.call('lpush', queue, cjson.encode(j))
+      requeuedCount = requeuedCount + 1
+      found = true
+      break
+    end
+  end
+  if not found then
+    j['err'] = 'unknown job when requeueing'
+    j['failed_at'] = tonumber(ARGV[2])
+    redis.call('zadd', KEYS[2], ARGV[2], cjson.encode(j))
+  end
+end
+return {jobCount, requeuedCount}
+`
+
 // KEYS[1] = job queue to push onto
 // KEYS[2] = Unique job's key. Test for existance and set if we push.
 // ARGV[1] = job
+// ARGV[2] = job ID, stored as the unique key's value so that its owner is known
 var redisLuaEnqueueUnique = `
-if redis.call('set', KEYS[2], '1', 'NX', 'EX', '86400') then
+if redis.call('set', KEYS[2], ARGV[2], 'NX', 'EX', '86400') then
   redis.call('lpush', KEYS[1], ARGV[1])
   return 'ok'
 end
@@ -239,10 +390,24 @@ return 'dup'
 // KEYS[2] = Unique job's key. Test for existance and set if we push.
 // ARGV[1] = job
 // ARGV[2] = epoch seconds for job to be run at
+// ARGV[3] = job ID, stored as the unique key's value so that its owner is known
 var redisLuaEnqueueUniqueIn = `
-if redis.call('set', KEYS[2], '1', 'NX', 'EX', '86400') then
+if redis.call('set', KEYS[2], ARGV[3], 'NX', 'EX', '86400') then
   redis.call('zadd', KEYS[1], ARGV[2], ARGV[1])
   return 'ok'
 end
 return 'dup'
 `
+
+// KEYS[1...] = unique job keys, eg ["work:unique:emails:{\"a\":1}", ...]
+// ARGV[1...] = ID of the job that owns each key
+// Returns: the number of keys deleted. A key is only deleted if it's still owned by its job.
+var redisLuaDeleteOwnedUniqueKeysCmd = `
+local deleted = 0
+for i = 1, #KEYS do
+  if redis.call('get', KEYS[i]) == ARGV[i] then
+    deleted = deleted + redis.call('del', KEYS[i])
+  end
+end
+return deleted
+`
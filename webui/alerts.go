@@ -0,0 +1,160 @@
+package webui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/web"
+)
+
+// alertWebhookTimeout bounds each webhook call, so that a slow receiver can't hold up the other samplers.
+const alertWebhookTimeout = 5 * time.Second
+
+// alert is the JSON body posted to the alert webhook when a job name's dead jobs reach its threshold.
+type alert struct {
+	JobName   string `json:"job_name"`
+	DeadJobs  int64  `json:"dead_jobs"`
+	Threshold int64  `json:"threshold"`
+}
+
+// alertWatcher posts to a webhook when the number of dead jobs of a job name reaches the threshold set for it in
+// /alert_thresholds. It alerts once per crossing: a job name alerts again only after dropping back below its threshold.
+type alertWatcher struct {
+	url    string
+	client *http.Client
+
+	mtx     sync.Mutex
+	alerted map[string]bool
+}
+
+func newAlertWatcher(url string) *alertWatcher {
+	return &alertWatcher{
+		url:     url,
+		client:  &http.Client{Timeout: alertWebhookTimeout},
+		alerted: map[string]bool{},
+	}
+}
+
+// watchAlerts is run by the sampler to compare dead job counts with the alert thresholds.
+func (w *Server) watchAlerts() {
+	thresholds, err := w.alertThresholds()
+	if err != nil || len(thresholds) == 0 {
+		return
+	}
+
+	jobs, err := w.readClient.AllDeadJobs()
+	if err != nil {
+		return
+	}
+	counts := map[string]int64{}
+	for _, j := range jobs {
+		counts[j.Name]++
+	}
+
+	w.alertWatcher.check(thresholds, counts)
+}
+
+// check alerts for each job name whose dead job count has newly reached its threshold.
+func (aw *alertWatcher) check(thresholds, deadCounts map[string]int64) {
+	aw.mtx.Lock()
+	defer aw.mtx.Unlock()
+
+	for jobName, threshold := range thresholds {
+		over := deadCounts[jobName] >= threshold
+		if over && !aw.alerted[jobName] {
+			if err := aw.post(&alert{JobName: jobName, DeadJobs: deadCounts[jobName], Threshold: threshold}); err != nil {
+				// Try again on the next sample
+				continue
+			}
+		}
+		aw.alerted[jobName] = over
+	}
+}
+
+// reset forgets which job names have alerted, so that any still over their threshold alert again. It returns the
+// number of job names it was tracking.
+func (aw *alertWatcher) reset() int {
+	aw.mtx.Lock()
+	defer aw.mtx.Unlock()
+
+	n := len(aw.alerted)
+	aw.alerted = map[string]bool{}
+	return n
+}
+
+func (aw *alertWatcher) post(a *alert) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	resp, err := aw.client.Post(aw.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *Server) alertThresholds() (map[string]int64, error) {
+	conn := w.pool.Get()
+	defer conn.Close()
+
+	return redis.Int64Map(conn.Do("HGETALL", redisKeyAlertThresholds(w.namespace)))
+}
+
+// alertThresholdsList returns the dead job count threshold of each job name that has one.
+func (c *context) alertThresholdsList(rw web.ResponseWriter, r *web.Request) {
+	thresholds, err := c.alertThresholds()
+	render(rw, thresholds, err)
+}
+
+// setAlertThreshold sets the dead job count threshold of the job_name form field to the threshold form field. A
+// threshold of 0 removes it.
+func (c *context) setAlertThreshold(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	jobName := r.Form.Get("job_name")
+	if jobName == "" {
+		renderError(rw, badRequestError("job_name is required"))
+		return
+	}
+
+	threshold, err := strconv.ParseInt(r.Form.Get("threshold"), 10, 64)
+	if err != nil {
+		renderError(rw, badParam("threshold", err))
+		return
+	}
+	if threshold < 0 {
+		renderError(rw, badRequestError("threshold can't be negative"))
+		return
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	if threshold == 0 {
+		_, err = conn.Do("HDEL", redisKeyAlertThresholds(c.namespace), jobName)
+	} else {
+		_, err = conn.Do("HSET", redisKeyAlertThresholds(c.namespace), jobName, threshold)
+	}
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	c.alertThresholdsList(rw, r)
+}
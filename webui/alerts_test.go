@@ -0,0 +1,88 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIAlertThresholds(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	var mtx sync.Mutex
+	var alerts []alert
+	webhook := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var a alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err == nil {
+			mtx.Lock()
+			alerts = append(alerts, a)
+			mtx.Unlock()
+		}
+	}))
+	defer webhook.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "", WithAlertWebhook(webhook.URL))
+
+	setThreshold := func(jobName, threshold string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/alert_thresholds", strings.NewReader("job_name="+jobName+"&threshold="+threshold))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, 200, setThreshold("wat", "2").Code)
+	assert.Equal(t, 200, setThreshold("foo", "1").Code)
+	assert.Equal(t, 200, setThreshold("bar", "5").Code)
+	assert.Equal(t, 200, setThreshold("bar", "0").Code)
+	assert.Equal(t, 400, setThreshold("", "1").Code)
+	assert.Equal(t, 400, setThreshold("wat", "-1").Code)
+	assert.Equal(t, 400, setThreshold("wat", "lots").Code)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/alert_thresholds", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var thresholds map[string]int64
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &thresholds))
+	assert.Equal(t, map[string]int64{"wat": 2, "foo": 1}, thresholds)
+
+	// wat is under its threshold, and baz has no threshold
+	insertDeadJob(ns, pool, "wat", 1, 10)
+	insertDeadJob(ns, pool, "baz", 1, 11)
+	s.watchAlerts()
+	assert.Equal(t, 0, len(alerts))
+
+	insertDeadJob(ns, pool, "wat", 1, 12)
+	insertDeadJob(ns, pool, "foo", 1, 13)
+	s.watchAlerts()
+	mtx.Lock()
+	if assert.Equal(t, 2, len(alerts)) {
+		byName := map[string]alert{alerts[0].JobName: alerts[0], alerts[1].JobName: alerts[1]}
+		assert.Equal(t, alert{JobName: "wat", DeadJobs: 2, Threshold: 2}, byName["wat"])
+		assert.Equal(t, alert{JobName: "foo", DeadJobs: 1, Threshold: 1}, byName["foo"])
+	}
+	mtx.Unlock()
+
+	// Still over, so no new alerts
+	insertDeadJob(ns, pool, "wat", 1, 14)
+	s.watchAlerts()
+	assert.Equal(t, 2, len(alerts))
+
+	// Raising the threshold puts wat back under it, and it alerts again once it crosses it
+	assert.Equal(t, 200, setThreshold("wat", "4").Code)
+	s.watchAlerts()
+	assert.Equal(t, 2, len(alerts))
+	insertDeadJob(ns, pool, "wat", 1, 15)
+	s.watchAlerts()
+	if assert.Equal(t, 3, len(alerts)) {
+		assert.Equal(t, alert{JobName: "wat", DeadJobs: 4, Threshold: 4}, alerts[2])
+	}
+}
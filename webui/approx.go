@@ -0,0 +1,59 @@
+package webui
+
+import "math"
+
+const (
+	// approxSampleSize is how many jobs are sampled to estimate counts in approx mode.
+	approxSampleSize = 1000
+
+	// approxConfidence is the confidence level of the error bounds of estimated counts, and approxZ the matching
+	// z-score of the normal distribution.
+	approxConfidence = 0.95
+	approxZ          = 1.96
+)
+
+// approxCount is an estimated count, which is within ErrorBound of the exact count with approxConfidence confidence.
+type approxCount struct {
+	Estimate   int64 `json:"estimate"`
+	ErrorBound int64 `json:"error_bound"`
+}
+
+// approxCounts is the response of a counting endpoint in approx mode. Approximate is false when the sample was the
+// whole set, in which case the counts are exact and their error bounds are 0.
+type approxCounts struct {
+	Approximate bool                    `json:"approximate"`
+	Total       int64                   `json:"total"`
+	SampleSize  int64                   `json:"sample_size"`
+	Confidence  float64                 `json:"confidence"`
+	Counts      map[string]*approxCount `json:"counts"`
+}
+
+// newApproxCounts extrapolates counts over a uniform random sample of sampleSize out of total items to the whole set.
+// Each count's error bound is the confidence interval of a sampled proportion, narrowed by the finite population
+// correction since the sample is drawn without replacement.
+func newApproxCounts(sampled map[string]int64, sampleSize, total int64) *approxCounts {
+	res := &approxCounts{
+		Approximate: sampleSize < total,
+		Total:       total,
+		SampleSize:  sampleSize,
+		Confidence:  approxConfidence,
+		Counts:      make(map[string]*approxCount, len(sampled)),
+	}
+
+	for k, n := range sampled {
+		if !res.Approximate {
+			res.Counts[k] = &approxCount{Estimate: n}
+			continue
+		}
+
+		p := float64(n) / float64(sampleSize)
+		fpc := float64(total-sampleSize) / float64(total-1)
+		stdErr := math.Sqrt(p * (1 - p) / float64(sampleSize) * fpc)
+		res.Counts[k] = &approxCount{
+			Estimate:   int64(math.Round(p * float64(total))),
+			ErrorBound: int64(math.Ceil(approxZ * stdErr * float64(total))),
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,91 @@
+package webui
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+// argsEllipsis is appended to arg values cut short by truncate_args.
+const argsEllipsis = "…"
+
+// parseTruncateArgs returns the truncate_args param of the list endpoints, or 0 if args shouldn't be truncated.
+func parseTruncateArgs(r *web.Request) (int, error) {
+	nStr := r.URL.Query().Get("truncate_args")
+	if nStr == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(nStr, 10, 31)
+	if err != nil {
+		return 0, badParam("truncate_args", err)
+	}
+	return int(n), nil
+}
+
+// listArgs returns the job's args for a list response, with each arg's serialized value truncated to n bytes. Detail
+// responses always have the full args.
+func listArgs(job *work.Job, n int) (json.RawMessage, error) {
+	args, err := job.ArgsJSON()
+	if err != nil || n <= 0 {
+		return args, err
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(args, &obj); err != nil || obj == nil {
+		// Args that aren't an object are truncated as a whole
+		return truncateArg(args, n), nil
+	}
+
+	for k, v := range obj {
+		obj[k] = truncateArg(v, n)
+	}
+	return json.Marshal(obj)
+}
+
+// truncateArg returns v unchanged if it's at most n bytes long. Otherwise it returns a string of the first n bytes of
+// v, cut back to a whole UTF-8 character, followed by argsEllipsis.
+func truncateArg(v json.RawMessage, n int) json.RawMessage {
+	if len(v) <= n {
+		return v
+	}
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	b, _ := json.Marshal(string(v[:n]) + argsEllipsis)
+	return b
+}
+
+// argsAtPath returns the value at the dot-separated path within args, eg "user.address.city", or null if there's
+// nothing there. A segment that's a number indexes into an array.
+func argsAtPath(args json.RawMessage, path string) json.RawMessage {
+	null := json.RawMessage("null")
+
+	v := args
+	for _, segment := range strings.Split(path, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(v, &obj); err == nil {
+			var ok bool
+			if v, ok = obj[segment]; !ok {
+				return null
+			}
+			continue
+		}
+
+		var arr []json.RawMessage
+		if err := json.Unmarshal(v, &arr); err == nil {
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(arr) {
+				return null
+			}
+			v = arr[i]
+			continue
+		}
+
+		return null
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUITruncateArgs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	long := strings.Repeat("x", 100)
+	insertDeadJobFull(ns, pool, &work.Job{
+		Name:     "wat",
+		ID:       "abc",
+		FailedAt: 10,
+		Args:     map[string]interface{}{"long": long, "short": 1},
+	})
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	getArgs := func(path string) map[string]interface{} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res struct {
+			Args map[string]interface{} `json:"args"`
+			Jobs []struct {
+				Args map[string]interface{} `json:"args"`
+			} `json:"jobs"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		if len(res.Jobs) == 1 {
+			return res.Jobs[0].Args
+		}
+		return res.Args
+	}
+
+	truncated := map[string]interface{}{"long": `"xxxxxxxxx` + argsEllipsis, "short": float64(1)}
+	assert.Equal(t, truncated, getArgs("/dead_jobs?truncate_args=10"))
+	assert.Equal(t, truncated, getArgs("/failing_jobs?truncate_args=10"))
+
+	full := map[string]interface{}{"long": long, "short": float64(1)}
+	assert.Equal(t, full, getArgs("/dead_jobs"))
+	assert.Equal(t, full, getArgs("/job/abc/state?truncate_args=10"))
+}
+
+func TestTruncateArg(t *testing.T) {
+	assert.Equal(t, `"abc"`, string(truncateArg([]byte(`"abc"`), 5)))
+	assert.Equal(t, `"[1,2…"`, string(truncateArg([]byte(`[1,2,3]`), 4)))
+	// Multi-byte characters aren't split
+	assert.Equal(t, `"\"é…"`, string(truncateArg([]byte(`"éé"`), 4)))
+}
+
+func TestArgsAtPath(t *testing.T) {
+	args := json.RawMessage(`{"a":1,"user":{"name":"bob","tags":["x",{"y":true}]},"none":null}`)
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"a", `1`},
+		{"user.name", `"bob"`},
+		{"user", `{"name":"bob","tags":["x",{"y":true}]}`},
+		{"user.tags.0", `"x"`},
+		{"user.tags.1.y", `true`},
+		{"none", `null`},
+		{"missing", `null`},
+		{"user.missing", `null`},
+		{"a.b", `null`},
+		{"user.tags.2", `null`},
+		{"user.tags.x", `null`},
+		{"user.name.first", `null`},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, string(argsAtPath(args, c.path)), c.path)
+	}
+
+	assert.Equal(t, `null`, string(argsAtPath(json.RawMessage(`null`), "a")))
+}
@@ -0,0 +1,42 @@
+package webui
+
+import (
+	"net/http"
+
+	"github.com/gocraft/web"
+)
+
+// ClientCertRequired rejects requests that don't present a verified client certificate with an allowed subject, and
+// authenticates the others as the certificate's subject. The health check is let through without one, though probes
+// can only reach it if the server's tls.Config doesn't require client certificates in the handshake.
+func (c *context) ClientCertRequired(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	if r.URL.Path == healthzPath {
+		next(rw, r)
+		return
+	}
+
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+		http.Error(rw, "Not authorized", 401)
+		return
+	}
+
+	subject := r.TLS.PeerCertificates[0].Subject.CommonName
+	if !c.config.certSubjects[subject] {
+		http.Error(rw, "Not authorized", 403)
+		return
+	}
+	c.user = subject
+
+	next(rw, r)
+}
+
+// credentialsRequired applies AdminRequired to every request but the health check, so that the JSON API needs the same
+// credentials as the HTML UI while probes can still reach the health check.
+func (c *context) credentialsRequired(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	if r.URL.Path == healthzPath {
+		next(rw, r)
+		return
+	}
+
+	c.AdminRequired(rw, r, next)
+}
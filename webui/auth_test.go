@@ -0,0 +1,215 @@
+package webui
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/web"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifiedCertState is the TLS state of a connection that presented a verified client certificate for subject.
+func verifiedCertState(subject string) *tls.ConnectionState {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: subject}}
+	return &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}
+}
+
+func TestWebUIClientCertAuth(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithClientCertAuth("billing-service"))
+
+	certFor := func(cn string) *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	}
+
+	cases := []struct {
+		name  string
+		state *tls.ConnectionState
+		code  int
+	}{
+		{"no tls", nil, 401},
+		{"no cert", &tls.ConnectionState{}, 401},
+		{
+			"unverified cert",
+			&tls.ConnectionState{PeerCertificates: []*x509.Certificate{certFor("billing-service")}},
+			401,
+		},
+		{
+			"untrusted subject",
+			&tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{certFor("intruder")},
+				VerifiedChains:   [][]*x509.Certificate{{certFor("intruder")}},
+			},
+			403,
+		},
+		{
+			"trusted subject",
+			&tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{certFor("billing-service")},
+				VerifiedChains:   [][]*x509.Certificate{{certFor("billing-service")}},
+			},
+			200,
+		},
+	}
+
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/queues", nil)
+		request.TLS = tc.state
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, tc.code, recorder.Code, tc.name)
+	}
+}
+
+func TestWebUIClientCertAuthUser(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	var out bytes.Buffer
+	s := NewServer(ns, pool, ":6666", "", "", WithClientCertAuth("billing-service"), WithRequestLog(&out, RequestLogText))
+
+	// The certificate's subject is the user, whatever basic auth claims
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/queues", nil)
+	request.TLS = verifiedCertState("billing-service")
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, out.String(), " user=billing-service ")
+}
+
+func TestAdminRequired(t *testing.T) {
+	router := web.New(context{})
+	router.Middleware(func(c *context, rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+		c.Admin = &Admin{Username: "admin", Password: "secret"}
+		next(rw, r)
+	})
+	router.Middleware((*context).AdminRequired)
+	router.Get("/", func(c *context, rw web.ResponseWriter, r *web.Request) {
+		rw.Write([]byte("ok"))
+	})
+
+	cases := []struct {
+		name               string
+		username, password string
+		code               int
+	}{
+		{"both correct", "admin", "secret", 200},
+		{"correct username only", "admin", "guess", 401},
+		{"correct password only", "guess", "secret", 401},
+		{"neither correct", "guess", "guess", 401},
+	}
+
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/", nil)
+		request.SetBasicAuth(tc.username, tc.password)
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, tc.code, recorder.Code, tc.name)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, 401, recorder.Code, "no credentials")
+}
+
+func TestAdminRequiredBearerToken(t *testing.T) {
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:secret"))
+
+	cases := []struct {
+		name          string
+		admin         *Admin
+		authorization string
+		code          int
+	}{
+		{"matching token", &Admin{Username: "admin", Password: "secret", Token: "s3cr3t"}, "Bearer s3cr3t", 200},
+		{"lowercase scheme", &Admin{Username: "admin", Password: "secret", Token: "s3cr3t"}, "bearer s3cr3t", 200},
+		{"wrong token", &Admin{Username: "admin", Password: "secret", Token: "s3cr3t"}, "Bearer guess", 401},
+		{"basic alongside a token", &Admin{Username: "admin", Password: "secret", Token: "s3cr3t"}, basic, 200},
+		{"token only", &Admin{Token: "s3cr3t"}, "Bearer s3cr3t", 200},
+		{"empty basic with token only", &Admin{Token: "s3cr3t"}, "Basic " + base64.StdEncoding.EncodeToString([]byte(":")), 401},
+		{"no token configured", &Admin{Username: "admin", Password: "secret"}, "Bearer ", 401},
+		{"other scheme", &Admin{Username: "admin", Password: "secret", Token: "s3cr3t"}, "Token s3cr3t", 401},
+	}
+
+	for _, tc := range cases {
+		admin := tc.admin
+		router := web.New(context{})
+		router.Middleware(func(c *context, rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+			c.Admin = admin
+			next(rw, r)
+		})
+		router.Middleware((*context).AdminRequired)
+		router.Get("/", func(c *context, rw web.ResponseWriter, r *web.Request) {
+			rw.Write([]byte("ok"))
+		})
+
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/", nil)
+		request.Header.Set("Authorization", tc.authorization)
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, tc.code, recorder.Code, tc.name)
+	}
+}
+
+func TestAdminRequiredWithoutCredentials(t *testing.T) {
+	router := web.New(context{})
+	router.Middleware(func(c *context, rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+		c.Admin = &Admin{}
+		next(rw, r)
+	})
+	router.Middleware((*context).AdminRequired)
+	router.Get("/", func(c *context, rw web.ResponseWriter, r *web.Request) {
+		rw.Write([]byte("ok"))
+	})
+
+	// Empty credentials don't match an unconfigured admin
+	for _, withAuth := range []bool{false, true} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/", nil)
+		if withAuth {
+			request.SetBasicAuth("", "")
+		}
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, 401, recorder.Code)
+	}
+}
+
+func TestWebUIAPICredentials(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+	s := NewServer(ns, pool, ":6666", "admin", "secret", WithBearerToken("s3cr3t"))
+
+	get := func(path string, setAuth func(*http.Request)) int {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		setAuth(request)
+		s.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	noAuth := func(r *http.Request) {}
+
+	// The JSON API needs the same credentials as the UI
+	assert.Equal(t, 401, get("/queues", noAuth))
+	assert.Equal(t, 401, get("/queues", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }))
+	assert.Equal(t, 200, get("/queues", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t") }))
+	assert.Equal(t, 200, get("/queues", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }))
+
+	// Except for the health check, so that probes don't need them
+	assert.Equal(t, 200, get(healthzPath, noAuth))
+}
@@ -0,0 +1,112 @@
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/web"
+)
+
+// baseline is a snapshot of the queue depths, eg taken before a deploy, to compare later depths with.
+type baseline struct {
+	CapturedAt int64            `json:"captured_at"`
+	Depths     map[string]int64 `json:"depths"`
+}
+
+// queueDepthDiff is how a queue's depth changed since the baseline.
+type queueDepthDiff struct {
+	JobName  string `json:"job_name"`
+	Baseline int64  `json:"baseline"`
+	Count    int64  `json:"count"`
+	Delta    int64  `json:"delta"`
+}
+
+// captureBaseline saves the current queue depths as the baseline, replacing any earlier one. It's kept in redis so
+// that every webui instance diffs against the same baseline.
+func (c *context) captureBaseline(rw web.ResponseWriter, r *web.Request) {
+	queues, err := c.client.Queues()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	b := &baseline{
+		CapturedAt: nowEpochSeconds(),
+		Depths:     make(map[string]int64, len(queues)),
+	}
+	for _, q := range queues {
+		b.Depths[q.JobName] = q.Count
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("SET", redisKeyBaseline(c.namespace), data)
+	render(rw, b, err)
+}
+
+// baselineDiff returns how each queue's depth changed since the baseline. Queues that didn't exist when the baseline
+// was captured have a baseline of 0. It 404s if no baseline has been captured.
+func (c *context) baselineDiff(rw web.ResponseWriter, r *web.Request) {
+	conn := c.pool.Get()
+	data, err := redis.Bytes(conn.Do("GET", redisKeyBaseline(c.namespace)))
+	conn.Close()
+	if err == redis.ErrNil {
+		renderErrorCode(rw, http.StatusNotFound, errors.New("no baseline captured"))
+		return
+	} else if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	var b baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	queues, err := c.readClient.Queues()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	diffs := make(map[string]*queueDepthDiff, len(queues))
+	for jobName, depth := range b.Depths {
+		diffs[jobName] = &queueDepthDiff{JobName: jobName, Baseline: depth}
+	}
+	for _, q := range queues {
+		d, ok := diffs[q.JobName]
+		if !ok {
+			d = &queueDepthDiff{JobName: q.JobName}
+			diffs[q.JobName] = d
+		}
+		d.Count = q.Count
+	}
+
+	response := struct {
+		CapturedAt int64             `json:"captured_at"`
+		Queues     []*queueDepthDiff `json:"queues"`
+	}{
+		CapturedAt: b.CapturedAt,
+		Queues:     make([]*queueDepthDiff, 0, len(diffs)),
+	}
+	for _, d := range diffs {
+		d.Delta = d.Count - d.Baseline
+		response.Queues = append(response.Queues, d)
+	}
+	sort.Slice(response.Queues, func(i, j int) bool {
+		return response.Queues[i].JobName < response.Queues[j].JobName
+	})
+
+	render(rw, response, nil)
+}
@@ -0,0 +1,77 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIBaselineDiff(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest(method, path, nil)
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("GET", "/baseline/diff")
+	assert.Equal(t, 404, recorder.Code)
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	enqueue := func(jobName string, n int) {
+		for i := 0; i < n; i++ {
+			_, err := enqueuer.Enqueue(jobName, nil)
+			assert.NoError(t, err)
+		}
+	}
+	enqueue("wat", 2)
+	enqueue("foo", 3)
+
+	recorder = serve("POST", "/baseline")
+	assert.Equal(t, 200, recorder.Code)
+	var captured baseline
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &captured))
+	assert.Equal(t, baseline{CapturedAt: 1425263409, Depths: map[string]int64{"wat": 2, "foo": 3}}, captured)
+
+	enqueue("wat", 5)
+	enqueue("bar", 1)
+	conn := pool.Get()
+	_, err := conn.Do("DEL", ns+":jobs:foo")
+	assert.NoError(t, err)
+	conn.Close()
+
+	recorder = serve("GET", "/baseline/diff")
+	assert.Equal(t, 200, recorder.Code)
+	var res struct {
+		CapturedAt int64            `json:"captured_at"`
+		Queues     []queueDepthDiff `json:"queues"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.EqualValues(t, 1425263409, res.CapturedAt)
+	assert.Equal(t, []queueDepthDiff{
+		{JobName: "bar", Baseline: 0, Count: 1, Delta: 1},
+		{JobName: "foo", Baseline: 3, Count: 0, Delta: -3},
+		{JobName: "wat", Baseline: 2, Count: 7, Delta: 5},
+	}, res.Queues)
+
+	// Capturing again replaces the baseline
+	recorder = serve("POST", "/baseline")
+	assert.Equal(t, 200, recorder.Code)
+	assert.NoError(t, json.Unmarshal(serve("GET", "/baseline/diff").Body.Bytes(), &res))
+	for _, q := range res.Queues {
+		assert.EqualValues(t, 0, q.Delta, q.JobName)
+	}
+}
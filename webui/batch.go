@@ -0,0 +1,159 @@
+package webui
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gocraft/web"
+)
+
+// maxBatchSize is the most sub-requests a /batch request can have.
+const maxBatchSize = 100
+
+// batchSubRequestKey marks the context of a /batch sub-request.
+type batchSubRequestKey struct{}
+
+// isBatchSubRequest returns whether r is a sub-request of a /batch request.
+func isBatchSubRequest(r *web.Request) bool {
+	return r.Context().Value(batchSubRequestKey{}) != nil
+}
+
+// batchRequest is one of the sub-requests of a /batch request. A body that's a JSON string is sent as is, as a form,
+// since that's what most of the API's POST endpoints read; any other JSON value is sent as a JSON body.
+type batchRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// batchResponse is the outcome of a sub-request. A body that isn't JSON, eg the HTML UI, is returned as a string.
+type batchResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// batchResponseWriter buffers a sub-request's response.
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// batch runs the sub-requests in the JSON array body one after another, in order, and returns their responses in the
+// same order. Each goes through the router like a request of its own, with the batch request's headers, so it's
+// authorized and logged the same way; a failed sub-request doesn't stop the later ones. Sub-requests run in the batch
+// request's slot of WithMaxInFlight's limit and of the redis pool admission rather than waiting for slots of their own,
+// which the batch request would hold up.
+func (c *context) batch(rw web.ResponseWriter, r *web.Request) {
+	// However a sub-request's path was spelled, it can't run a batch of its own
+	if isBatchSubRequest(r) {
+		renderError(rw, badRequestError("batches can't be nested"))
+		return
+	}
+
+	var reqs []batchRequest
+	if err := decodeJSONBody(r, &reqs, c.config); err != nil {
+		renderError(rw, err)
+		return
+	}
+	if len(reqs) > maxBatchSize {
+		renderError(rw, badRequestError(fmt.Sprintf("a batch can have at most %d requests", maxBatchSize)))
+		return
+	}
+	for i, req := range reqs {
+		if !strings.HasPrefix(req.Path, "/") {
+			renderError(rw, badRequestError(fmt.Sprintf("request %d: path must start with /", i)))
+			return
+		}
+		if routePath(req.Path) == "/batch" {
+			renderError(rw, badRequestError(fmt.Sprintf("request %d: batches can't be nested", i)))
+			return
+		}
+		if routePath(req.Path) == deadJobEventsPath {
+			renderError(rw, badRequestError(fmt.Sprintf("request %d: streams can't be batched", i)))
+			return
+		}
+	}
+
+	responses := make([]*batchResponse, 0, len(reqs))
+	for _, req := range reqs {
+		res, err := c.serveBatchRequest(r, &req)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		responses = append(responses, res)
+	}
+
+	render(rw, responses, nil)
+}
+
+// serveBatchRequest runs a sub-request of the batch request r through the router.
+func (c *context) serveBatchRequest(r *web.Request, req *batchRequest) (*batchResponse, error) {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	var body []byte
+	contentType := ""
+	if len(req.Body) > 0 && string(req.Body) != "null" {
+		var form string
+		if err := json.Unmarshal(req.Body, &form); err == nil {
+			body = []byte(form)
+			contentType = "application/x-www-form-urlencoded"
+		} else {
+			body = req.Body
+			contentType = "application/json"
+		}
+	}
+
+	sub, err := http.NewRequest(method, req.Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, badRequestError(err.Error())
+	}
+	sub.Header = r.Header.Clone()
+	sub.Header.Del("Content-Length")
+	sub.Header.Del("Content-Type")
+	if contentType != "" {
+		sub.Header.Set("Content-Type", contentType)
+	}
+	sub.RemoteAddr = r.RemoteAddr
+	sub.TLS = r.TLS
+	sub = sub.WithContext(stdcontext.WithValue(r.Context(), batchSubRequestKey{}, true))
+
+	w := &batchResponseWriter{header: http.Header{}}
+	c.router.ServeHTTP(w, sub)
+
+	res := &batchResponse{Status: w.status, Body: w.body.Bytes()}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+	if w.body.Len() == 0 {
+		res.Body = json.RawMessage("null")
+	} else if !json.Valid(res.Body) {
+		res.Body, _ = json.Marshal(w.body.String())
+	}
+	return res, nil
+}
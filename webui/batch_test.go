@@ -0,0 +1,119 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIBatch(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithEnqueueAllowlist("wat"))
+
+	batch := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/batch", strings.NewReader(body))
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := batch(`[
+		{"method": "POST", "path": "/enqueue/wat", "body": {"a": 1}},
+		{"method": "GET", "path": "/queue/wat"},
+		{"method": "POST", "path": "/sampling_config", "body": "interval_secs=30"},
+		{"method": "GET", "path": "/job/nope/state"}
+	]`)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res []struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	if assert.Equal(t, 4, len(res)) {
+		// Sub-requests run in order, so the job enqueued by the first is counted by the second
+		assert.Equal(t, 200, res[0].Status)
+		var job struct {
+			Name string                 `json:"name"`
+			Args map[string]interface{} `json:"args"`
+		}
+		assert.NoError(t, json.Unmarshal(res[0].Body, &job))
+		assert.Equal(t, "wat", job.Name)
+		assert.EqualValues(t, 1, job.Args["a"])
+
+		assert.Equal(t, 200, res[1].Status)
+		var queue queueDetail
+		assert.NoError(t, json.Unmarshal(res[1].Body, &queue))
+		assert.EqualValues(t, 1, queue.Count)
+
+		assert.Equal(t, 200, res[2].Status)
+		assert.JSONEq(t, `{"interval_secs": 30, "meta": {"namespace": "testwork"}}`, string(res[2].Body))
+
+		assert.Equal(t, 404, res[3].Status)
+		assert.JSONEq(t, `{"error": "job not found"}`, string(res[3].Body))
+	}
+
+	recorder = batch(`[{"method": "POST", "path": "/batch", "body": []}]`)
+	assert.Equal(t, 400, recorder.Code)
+
+	// gocraft/web ignores trailing slashes, so these would reach the batch handler too
+	for _, path := range []string{"/batch/", "/batch/?x", "/./batch"} {
+		recorder = batch(`[{"method": "POST", "path": "` + path + `", "body": []}]`)
+		assert.Equal(t, 400, recorder.Code, path)
+		assert.Contains(t, recorder.Body.String(), "batches can't be nested", path)
+	}
+
+	recorder = batch(`[{"method": "GET", "path": "queues"}]`)
+	assert.Equal(t, 400, recorder.Code)
+
+	recorder = batch(``)
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `[]`, recorder.Body.String())
+
+	recorder = batch(`{"a":`)
+	assert.Equal(t, 400, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid request body")
+
+	recorder = batch(`{"method": "GET", "path": "/queues"}`)
+	assert.Equal(t, 400, recorder.Code)
+}
+
+func TestWebUIBatchMaxInFlight(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithMaxInFlight(1, 0), WithAcquireTimeout(10*time.Millisecond))
+
+	// The batch request takes the only in-flight slot and the last admission slot
+	for i := 0; i < pool.MaxActive-1; i++ {
+		s.admission.slots <- struct{}{}
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/batch", strings.NewReader(`[
+		{"method": "GET", "path": "/queues"},
+		{"method": "GET", "path": "/uptime"}
+	]`))
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res []struct {
+		Status int `json:"status"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	if assert.Equal(t, 2, len(res)) {
+		assert.Equal(t, 200, res[0].Status)
+		assert.Equal(t, 200, res[1].Status)
+	}
+	assert.Equal(t, 0, len(s.inFlight.slots))
+	assert.Equal(t, pool.MaxActive-1, len(s.admission.slots))
+}
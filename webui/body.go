@@ -0,0 +1,77 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/gocraft/web"
+)
+
+const (
+	defaultMaxJSONDepth    = 32
+	defaultMaxJSONBodySize = 1 << 20
+)
+
+// decodeJSONBody decodes the request's JSON body into v, leaving v untouched if the body is empty. Bodies that aren't
+// valid JSON of v's type are a bad request, and so are bodies larger than the configured max size, which are rejected
+// without reading the rest of them, and bodies nested deeper than the configured max depth of arrays and objects,
+// checked before decoding so that the decoder never has to recurse that deep.
+func decodeJSONBody(r *web.Request, v interface{}, cfg *config) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, cfg.maxJSONBodySize+1))
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if int64(len(b)) > cfg.maxJSONBodySize {
+		return badRequestError(fmt.Sprintf("request body is larger than %d bytes", cfg.maxJSONBodySize))
+	}
+
+	if depth := jsonDepth(b, cfg.maxJSONDepth); depth > cfg.maxJSONDepth {
+		return badRequestError(fmt.Sprintf("request body is nested deeper than %d levels", cfg.maxJSONDepth))
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return badRequestError(fmt.Sprintf("invalid request body: %v", err))
+	}
+	return nil
+}
+
+// jsonDepth returns how deeply the arrays and objects in b are nested, stopping as soon as it exceeds max. It doesn't
+// validate b; malformed JSON is left for the decoder to reject.
+func jsonDepth(b []byte, max int) int {
+	depth, deepest := 0, 0
+	inString, escaped := false, false
+	for _, c := range b {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+			if depth > deepest {
+				deepest = depth
+				if deepest > max {
+					return deepest
+				}
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return deepest
+}
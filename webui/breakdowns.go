@@ -0,0 +1,177 @@
+package webui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+func (c *context) deadJobCategories(rw web.ResponseWriter, r *web.Request) {
+	c.countDeadJobs(rw, r, func(j *work.DeadJob) string {
+		return c.errorCategory(j.LastErr)
+	})
+}
+
+// deadJobsByQueue counts dead jobs by the queue they came from. Each job name has its own queue.
+func (c *context) deadJobsByQueue(rw web.ResponseWriter, r *web.Request) {
+	c.countDeadJobs(rw, r, func(j *work.DeadJob) string {
+		return j.Name
+	})
+}
+
+// countDeadJobs renders the number of dead jobs with each key. With the approx=true param, the counts are estimated
+// from a random sample of approxSampleSize dead jobs instead of read from all of them; see approxCounts.
+func (c *context) countDeadJobs(rw web.ResponseWriter, r *web.Request, key func(*work.DeadJob) string) {
+	if r.URL.Query().Get("approx") == "true" {
+		jobs, total, err := c.readClient.SampleDeadJobs(approxSampleSize)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+
+		counts := map[string]int64{}
+		for _, j := range jobs {
+			counts[key(j)]++
+		}
+		render(rw, newApproxCounts(counts, int64(len(jobs)), total), nil)
+		return
+	}
+
+	jobs, err := c.readClient.AllDeadJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	counts := map[string]int64{}
+	for _, j := range jobs {
+		counts[key(j)]++
+	}
+
+	render(rw, counts, nil)
+}
+
+// errorCategory returns the label of the first configured ErrorCategory matching errStr, or errStr itself if none match.
+func (c *context) errorCategory(errStr string) string {
+	for _, ec := range c.config.errorCategories {
+		if ec.Pattern.MatchString(errStr) {
+			return ec.Label
+		}
+	}
+	return errStr
+}
+
+// defaultHistogramBucket is the bucket width, in seconds, used by histograms when the request doesn't give one.
+const defaultHistogramBucket = 60
+
+type histogramBucket struct {
+	Start int64 `json:"start"`
+	Count int64 `json:"count"`
+}
+
+// retryJobsHistogram counts retry jobs by when they're scheduled to be retried, in buckets of the given number of
+// seconds. Buckets are ordered by start time, and empty buckets are left out.
+func (c *context) retryJobsHistogram(rw web.ResponseWriter, r *web.Request) {
+	bucket, err := parseHistogramBucket(r, "bucket")
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	jobs, err := c.readClient.AllRetryJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	times := make([]int64, 0, len(jobs))
+	for _, j := range jobs {
+		times = append(times, j.RetryAt)
+	}
+
+	response := struct {
+		Bucket  int64              `json:"bucket"`
+		Buckets []*histogramBucket `json:"buckets"`
+	}{Bucket: bucket, Buckets: histogram(times, bucket)}
+
+	render(rw, response, nil)
+}
+
+// enqueueHistogramPeekLimit is how many of the oldest jobs in each queue are looked at by /enqueue_histogram.
+const enqueueHistogramPeekLimit = 1000
+
+// enqueueHistogram counts queued jobs by when they were enqueued, in buckets of bucket_secs seconds. Only the oldest
+// enqueueHistogramPeekLimit jobs of each queue are counted.
+func (c *context) enqueueHistogram(rw web.ResponseWriter, r *web.Request) {
+	bucket, err := parseHistogramBucket(r, "bucket_secs")
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	queues, err := c.readClient.Queues()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	var times []int64
+	for _, q := range queues {
+		jobs, err := c.readClient.PeekJobs(q.JobName, enqueueHistogramPeekLimit)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		for _, j := range jobs {
+			times = append(times, j.EnqueuedAt)
+		}
+	}
+
+	response := struct {
+		BucketSecs int64              `json:"bucket_secs"`
+		Buckets    []*histogramBucket `json:"buckets"`
+	}{BucketSecs: bucket, Buckets: histogram(times, bucket)}
+
+	render(rw, response, nil)
+}
+
+// parseHistogramBucket reads a bucket width in seconds from the query param named param.
+func parseHistogramBucket(r *web.Request, param string) (int64, error) {
+	bucketStr := r.URL.Query().Get(param)
+	if bucketStr == "" {
+		return defaultHistogramBucket, nil
+	}
+
+	bucket, err := strconv.ParseInt(bucketStr, 10, 64)
+	if err != nil {
+		return 0, badParam(param, err)
+	}
+	if bucket < 1 {
+		return 0, badRequestError(fmt.Sprintf("%s must be at least 1", param))
+	}
+	return bucket, nil
+}
+
+// histogram counts times into buckets of width seconds, aligned to multiples of width.
+func histogram(times []int64, width int64) []*histogramBucket {
+	counts := map[int64]int64{}
+	for _, t := range times {
+		start := t - t%width
+		if t < 0 && t%width != 0 {
+			start -= width
+		}
+		counts[start]++
+	}
+
+	buckets := make([]*histogramBucket, 0, len(counts))
+	for start, count := range counts {
+		buckets = append(buckets, &histogramBucket{Start: start, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start < buckets[j].Start
+	})
+	return buckets
+}
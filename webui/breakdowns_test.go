@@ -0,0 +1,274 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIDeadJobCategories(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertDeadJobWithError(ns, pool, "wat", 0, 1, "dial tcp 10.0.0.1:5432: i/o timeout")
+	insertDeadJobWithError(ns, pool, "wat", 0, 2, "dial tcp 10.0.0.2:5432: i/o timeout")
+	insertDeadJobWithError(ns, pool, "foo", 0, 3, "pq: duplicate key value violates unique constraint")
+	insertDeadJobWithError(ns, pool, "foo", 0, 4, "ohno")
+	insertDeadJobWithError(ns, pool, "bar", 0, 5, "ohno")
+
+	s := NewServer(ns, pool, ":6666", "", "", WithErrorCategories(
+		ErrorCategory{Pattern: regexp.MustCompile(`i/o timeout`), Label: "timeout"},
+		ErrorCategory{Pattern: regexp.MustCompile(`^pq: `), Label: "postgres"},
+		ErrorCategory{Pattern: regexp.MustCompile(`timeout`), Label: "never reached"},
+	))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/dead_jobs/categories", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res map[string]int64
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"timeout": 2, "postgres": 1, "ohno": 2}, res)
+
+	// Without rules, errors pass straight through.
+	s = NewServer(ns, pool, ":6666", "", "")
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs/categories", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	res = nil
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(res))
+	assert.EqualValues(t, 2, res["ohno"])
+}
+
+func TestWebUIDeadJobsByQueue(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertDeadJob(ns, pool, "wat", 0, 1)
+	insertDeadJob(ns, pool, "wat", 0, 2)
+	insertDeadJob(ns, pool, "wat", 0, 3)
+	insertDeadJob(ns, pool, "foo", 0, 4)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/dead_jobs/by_queue", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res map[string]int64
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"wat": 3, "foo": 1}, res)
+
+	cleanKeyspace(ns, pool)
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs/by_queue", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "{}", recorder.Body.String())
+}
+
+func TestWebUIDeadJobsByQueueApprox(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	// 5000 dead jobs, 60% wat, 30% foo and 10% bar
+	conn := pool.Get()
+	for i := 0; i < 5000; i++ {
+		name := "wat"
+		if i%10 >= 9 {
+			name = "bar"
+		} else if i%10 >= 6 {
+			name = "foo"
+		}
+		conn.Send("ZADD", ns+":dead", i, fmt.Sprintf(`{"name":"%s","id":"job-%d","t":1}`, name, i))
+	}
+	assert.NoError(t, conn.Flush())
+	for i := 0; i < 5000; i++ {
+		_, err := conn.Receive()
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	get := func(query string) []byte {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/dead_jobs/by_queue"+query, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		return recorder.Body.Bytes()
+	}
+
+	var exact map[string]int64
+	assert.NoError(t, json.Unmarshal(get(""), &exact))
+	assert.Equal(t, map[string]int64{"wat": 3000, "foo": 1500, "bar": 500}, exact)
+
+	var approx approxCounts
+	assert.NoError(t, json.Unmarshal(get("?approx=true"), &approx))
+	assert.True(t, approx.Approximate)
+	assert.EqualValues(t, 5000, approx.Total)
+	assert.EqualValues(t, approxSampleSize, approx.SampleSize)
+	assert.Equal(t, approxConfidence, approx.Confidence)
+	for name, count := range exact {
+		estimate := approx.Counts[name]
+		if assert.NotNil(t, estimate, name) {
+			// The bound holds 95% of the time; twice it holds all but vanishingly rarely
+			assert.True(t, estimate.ErrorBound > 0, name)
+			assert.InDelta(t, count, estimate.Estimate, float64(2*estimate.ErrorBound), name)
+		}
+	}
+}
+
+func TestNewApproxCounts(t *testing.T) {
+	// A sample of the whole set is exact
+	counts := newApproxCounts(map[string]int64{"wat": 3, "foo": 1}, 4, 4)
+	assert.False(t, counts.Approximate)
+	assert.Equal(t, map[string]*approxCount{"wat": {Estimate: 3}, "foo": {Estimate: 1}}, counts.Counts)
+
+	// 30% of a 1000 sample out of 1,000,000: 300000 ± 1.96 * sqrt(.3 * .7 / 1000 * 999000 / 999999) * 1000000
+	counts = newApproxCounts(map[string]int64{"wat": 300}, 1000, 1000000)
+	assert.True(t, counts.Approximate)
+	assert.EqualValues(t, 300000, counts.Counts["wat"].Estimate)
+	assert.EqualValues(t, 28389, counts.Counts["wat"].ErrorBound)
+}
+
+func TestWebUIRetryJobsHistogram(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertRetryJob(ns, pool, "wat", 1000, 1)
+	insertRetryJob(ns, pool, "wat", 1019, 2)
+	insertRetryJob(ns, pool, "wat", 1020, 3)
+	insertRetryJob(ns, pool, "foo", 1075, 4)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	type result struct {
+		Bucket  int64 `json:"bucket"`
+		Buckets []struct {
+			Start int64 `json:"start"`
+			Count int64 `json:"count"`
+		} `json:"buckets"`
+	}
+
+	var res result
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/retry_jobs/histogram?bucket=20", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, res.Bucket)
+	if assert.Equal(t, 3, len(res.Buckets)) {
+		assert.EqualValues(t, 1000, res.Buckets[0].Start)
+		assert.EqualValues(t, 2, res.Buckets[0].Count)
+		assert.EqualValues(t, 1020, res.Buckets[1].Start)
+		assert.EqualValues(t, 1, res.Buckets[1].Count)
+		assert.EqualValues(t, 1060, res.Buckets[2].Start)
+		assert.EqualValues(t, 1, res.Buckets[2].Count)
+	}
+
+	// Default bucket is a minute
+	res = result{}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/retry_jobs/histogram", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 60, res.Bucket)
+	if assert.Equal(t, 2, len(res.Buckets)) {
+		assert.EqualValues(t, 960, res.Buckets[0].Start)
+		assert.EqualValues(t, 2, res.Buckets[0].Count)
+		assert.EqualValues(t, 1020, res.Buckets[1].Start)
+		assert.EqualValues(t, 2, res.Buckets[1].Count)
+	}
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/retry_jobs/histogram?bucket=0", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+}
+
+func TestWebUIEnqueueHistogram(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertQueuedJob(ns, pool, "wat", 3600)
+	insertQueuedJob(ns, pool, "wat", 3601)
+	insertQueuedJob(ns, pool, "wat", 7199)
+	insertQueuedJob(ns, pool, "foo", 7200)
+	insertQueuedJob(ns, pool, "foo", 14500)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	type result struct {
+		BucketSecs int64 `json:"bucket_secs"`
+		Buckets    []struct {
+			Start int64 `json:"start"`
+			Count int64 `json:"count"`
+		} `json:"buckets"`
+	}
+
+	var res result
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/enqueue_histogram?bucket_secs=3600", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3600, res.BucketSecs)
+	if assert.Equal(t, 3, len(res.Buckets)) {
+		assert.EqualValues(t, 3600, res.Buckets[0].Start)
+		assert.EqualValues(t, 3, res.Buckets[0].Count)
+		assert.EqualValues(t, 7200, res.Buckets[1].Start)
+		assert.EqualValues(t, 1, res.Buckets[1].Count)
+		assert.EqualValues(t, 14400, res.Buckets[2].Start)
+		assert.EqualValues(t, 1, res.Buckets[2].Count)
+	}
+
+	for _, bad := range []string{"0", "-60", "hour"} {
+		recorder = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/enqueue_histogram?bucket_secs="+bad, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 400, recorder.Code)
+	}
+}
+
+func insertQueuedJob(ns string, pool *redis.Pool, name string, enqueuedAt int64) {
+	job := &work.Job{
+		Name:       name,
+		ID:         fmt.Sprintf("%s-%d", name, enqueuedAt),
+		EnqueuedAt: enqueuedAt,
+	}
+
+	rawJSON, _ := json.Marshal(job)
+
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("SADD", ns+":known_jobs", name); err != nil {
+		panic(err.Error())
+	}
+	if _, err := conn.Do("LPUSH", ns+":jobs:"+name, rawJSON); err != nil {
+		panic(err.Error())
+	}
+}
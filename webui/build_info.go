@@ -0,0 +1,60 @@
+package webui
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/gocraft/web"
+)
+
+// buildModule is a module the binary was built with.
+type buildModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// buildInfo identifies the running binary.
+type buildInfo struct {
+	GoVersion string         `json:"go_version"`
+	GOOS      string         `json:"goos"`
+	GOARCH    string         `json:"goarch"`
+	Path      string         `json:"path"`
+	Main      *buildModule   `json:"main"`
+	Modules   []*buildModule `json:"modules"`
+}
+
+// buildInfo returns the Go version, platform, and module versions the binary was built with, eg to confirm which
+// version of work is running. Binaries built without module support have no main module and an empty module list.
+func (c *context) buildInfo(rw web.ResponseWriter, r *web.Request) {
+	info := &buildInfo{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		Modules:   []*buildModule{},
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Path = bi.Path
+		if bi.Main.Path != "" {
+			info.Main = newBuildModule(&bi.Main)
+		}
+		for _, m := range bi.Deps {
+			info.Modules = append(info.Modules, newBuildModule(m))
+		}
+	}
+
+	render(rw, info, nil)
+}
+
+func newBuildModule(m *debug.Module) *buildModule {
+	bm := &buildModule{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		bm.Replace = m.Replace.Path
+		if m.Replace.Version != "" {
+			bm.Replace += "@" + m.Replace.Version
+		}
+	}
+	return bm
+}
@@ -0,0 +1,48 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIBuildInfo(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/buildinfo", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res map[string]interface{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Equal(t, runtime.Version(), res["go_version"])
+	assert.Equal(t, runtime.GOOS, res["goos"])
+	assert.Equal(t, runtime.GOARCH, res["goarch"])
+	// Without module support, eg in GOPATH mode, the list is empty but still there
+	assert.IsType(t, []interface{}{}, res["modules"])
+}
+
+func TestNewBuildModule(t *testing.T) {
+	// Exercised directly since test binaries don't necessarily have module info
+	m := newBuildModule(&debug.Module{
+		Path:    "github.com/gocraft/work",
+		Version: "v0.5.1",
+		Sum:     "h1:abc=",
+		Replace: &debug.Module{Path: "github.com/zier/work", Version: "v0.6.0"},
+	})
+	assert.Equal(t, &buildModule{
+		Path:    "github.com/gocraft/work",
+		Version: "v0.5.1",
+		Sum:     "h1:abc=",
+		Replace: "github.com/zier/work@v0.6.0",
+	}, m)
+}
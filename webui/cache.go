@@ -0,0 +1,30 @@
+package webui
+
+import (
+	"github.com/gocraft/web"
+)
+
+type cacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+// cacheStats reports the hits, misses, and current entries of the server's in-process caches, by cache.
+func (c *context) cacheStats(rw web.ResponseWriter, r *web.Request) {
+	response := struct {
+		Caches map[string]*cacheStats `json:"caches"`
+	}{Caches: map[string]*cacheStats{
+		"job_names": c.jobNames.stats(),
+	}}
+
+	render(rw, response, nil)
+}
+
+// clearCache empties the server's in-process caches, so that the next requests see what's in redis right away instead
+// of waiting for the cached data to expire.
+func (c *context) clearCache(rw web.ResponseWriter, r *web.Request) {
+	c.jobNames.clear()
+
+	render(rw, &statusResponse{Status: "ok"}, nil)
+}
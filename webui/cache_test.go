@@ -0,0 +1,60 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUICache(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	_, err := enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	do := func(method, path string) []byte {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest(method, path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		return recorder.Body.Bytes()
+	}
+
+	stats := func() *cacheStats {
+		var res struct {
+			Caches map[string]*cacheStats `json:"caches"`
+		}
+		err := json.Unmarshal(do("GET", "/cache/stats"), &res)
+		assert.NoError(t, err)
+		return res.Caches["job_names"]
+	}
+
+	assert.Equal(t, &cacheStats{}, stats())
+
+	do("GET", "/job_names/search?prefix=w")
+	do("GET", "/job_names/search?prefix=w")
+	assert.Equal(t, &cacheStats{Hits: 1, Misses: 1, Entries: 1}, stats())
+
+	// A job enqueued since doesn't show up until the cache is cleared
+	_, err = enqueuer.Enqueue("whoa", nil)
+	assert.NoError(t, err)
+	var names []string
+	assert.NoError(t, json.Unmarshal(do("GET", "/job_names/search?prefix=w"), &names))
+	assert.Equal(t, []string{"wat"}, names)
+
+	do("POST", "/cache/clear")
+	assert.Equal(t, &cacheStats{Hits: 2, Misses: 1, Entries: 0}, stats())
+
+	assert.NoError(t, json.Unmarshal(do("GET", "/job_names/search?prefix=w"), &names))
+	assert.Equal(t, []string{"wat", "whoa"}, names)
+	assert.Equal(t, &cacheStats{Hits: 2, Misses: 2, Entries: 2}, stats())
+}
@@ -0,0 +1,74 @@
+package webui
+
+import (
+	"sync"
+)
+
+// fanOut caps how many redis operations the server's parallel operations run at once, across all requests. Every
+// internal fan-out should go through the server's fanOut rather than starting its own goroutines.
+type fanOut struct {
+	slots chan struct{}
+}
+
+func newFanOut(size int) *fanOut {
+	if size < 1 {
+		size = 1
+	}
+	return &fanOut{slots: make(chan struct{}, size)}
+}
+
+// forEach is like forEachConcurrently, except that each call to fn also has to take one of the fanOut's slots.
+func (f *fanOut) forEach(n, concurrency int, fn func(i int) error) error {
+	return forEachConcurrently(n, concurrency, func(i int) error {
+		f.slots <- struct{}{}
+		defer func() { <-f.slots }()
+		return fn(i)
+	})
+}
+
+// forEachConcurrently calls fn for each index in [0, n) using at most concurrency goroutines at once. It waits for all
+// calls to finish and returns the first error any of them returned. Once a call fails, indexes that haven't started yet
+// are skipped.
+func forEachConcurrently(n, concurrency int, fn func(i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	var mtx sync.Mutex
+	var firstErr error
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(i); err != nil {
+					mtx.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mtx.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		mtx.Lock()
+		failed := firstErr != nil
+		mtx.Unlock()
+		if failed {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,141 @@
+package webui
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachConcurrently(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 3, 100} {
+		var calls, inFlight, maxInFlight int64
+		seen := make([]int64, 50)
+
+		err := forEachConcurrently(50, concurrency, func(i int) error {
+			n := atomic.AddInt64(&inFlight, 1)
+			for {
+				max := atomic.LoadInt64(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, n) {
+					break
+				}
+			}
+			atomic.AddInt64(&seen[i], 1)
+			atomic.AddInt64(&calls, 1)
+			atomic.AddInt64(&inFlight, -1)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 50, calls)
+		for i := range seen {
+			assert.EqualValues(t, 1, seen[i])
+		}
+		if concurrency > 0 {
+			assert.True(t, maxInFlight <= int64(concurrency))
+		}
+	}
+
+	err := forEachConcurrently(10, 2, func(i int) error {
+		if i == 3 {
+			return fmt.Errorf("ohno")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "ohno")
+}
+
+func TestFanOutSharedLimit(t *testing.T) {
+	f := newFanOut(3)
+
+	var inFlight, maxInFlight int64
+	fn := func(i int) error {
+		n := atomic.AddInt64(&inFlight, 1)
+		for {
+			max := atomic.LoadInt64(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt64(&inFlight, -1)
+		return nil
+	}
+
+	// Several operations each asking for more concurrency than the fanOut allows in total
+	var wg sync.WaitGroup
+	for op := 0; op < 4; op++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, f.forEach(20, 5, fn))
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, maxInFlight <= 3)
+}
+
+func TestWebUIFanOutSize(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "")
+	assert.Equal(t, pool.MaxActive, cap(s.fanOut.slots))
+
+	s = NewServer(ns, pool, ":6666", "", "", WithFanOutSize(7))
+	assert.Equal(t, 7, cap(s.fanOut.slots))
+
+	s = NewServer(ns, &redis.Pool{}, ":6666", "", "")
+	assert.Equal(t, runtime.GOMAXPROCS(0), cap(s.fanOut.slots))
+}
+
+func TestWebUIRetryAllDeadJobsConcurrency(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	for _, concurrency := range []int{1, 2, 8} {
+		cleanKeyspace(ns, pool)
+		for i := int64(0); i < 30; i++ {
+			insertDeadJobWithFails(ns, pool, "wat", 1000+i, 1)
+		}
+
+		s := NewServer(ns, pool, ":6666", "", "", WithRetryConcurrency(concurrency))
+
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/retry_all_dead_jobs?max_fails=5", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		assert.Regexp(t, `"retried": 30`, recorder.Body.String())
+		assert.EqualValues(t, 30, listSize(pool, ns+":jobs:wat"))
+	}
+}
+
+func BenchmarkRetryAllDeadJobs(b *testing.B) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			s := NewServer(ns, pool, ":6666", "", "", WithRetryConcurrency(concurrency))
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				cleanKeyspace(ns, pool)
+				for i := int64(0); i < 200; i++ {
+					insertDeadJobWithFails(ns, pool, "wat", 1000+i, 1)
+				}
+				b.StartTimer()
+
+				recorder := httptest.NewRecorder()
+				request, _ := http.NewRequest("POST", "/retry_all_dead_jobs?max_fails=5", nil)
+				s.router.ServeHTTP(recorder, request)
+			}
+		})
+	}
+}
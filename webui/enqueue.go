@@ -0,0 +1,50 @@
+package webui
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gocraft/web"
+)
+
+// enqueue enqueues a job named by the job_name path param. The optional request body is a JSON object of job args.
+func (c *context) enqueue(rw web.ResponseWriter, r *web.Request) {
+	jobName := r.PathParams["job_name"]
+
+	allowed, err := c.canEnqueue(jobName)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	if !allowed {
+		renderErrorCode(rw, http.StatusForbidden, fmt.Errorf("job %q can't be enqueued", jobName))
+		return
+	}
+
+	var args map[string]interface{}
+	if err := decodeJSONBody(r, &args, c.config); err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	job, err := c.enqueuer.Enqueue(jobName, args)
+	render(rw, job, err)
+}
+
+// canEnqueue checks jobName against the configured allowlist, or if there isn't one, against the known job names.
+func (c *context) canEnqueue(jobName string) (bool, error) {
+	if c.config.enqueueable != nil {
+		return c.config.enqueueable[jobName], nil
+	}
+
+	queues, err := c.client.Queues()
+	if err != nil {
+		return false, err
+	}
+	for _, q := range queues {
+		if q.JobName == jobName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
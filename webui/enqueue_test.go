@@ -0,0 +1,145 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIEnqueueAllowlist(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithEnqueueAllowlist("wat"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/enqueue/wat", strings.NewReader(`{"a": 1}`))
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		ID   string                 `json:"id"`
+		Name string                 `json:"name"`
+		Args map[string]interface{} `json:"args"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "wat", res.Name)
+	assert.NotEqual(t, "", res.ID)
+	assert.EqualValues(t, 1, res.Args["a"])
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:wat"))
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/enqueue/foo", strings.NewReader(`{"a": 1}`))
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 403, recorder.Code)
+	assert.EqualValues(t, 0, listSize(pool, ns+":jobs:foo"))
+}
+
+func TestWebUIEnqueueKnownJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":known_jobs", "foo")
+	conn.Close()
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/enqueue/foo", strings.NewReader(""))
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:foo"))
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/enqueue/bar", strings.NewReader(""))
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 403, recorder.Code)
+	assert.EqualValues(t, 0, listSize(pool, ns+":jobs:bar"))
+}
+
+func listSize(pool *redis.Pool, key string) int64 {
+	conn := pool.Get()
+	defer conn.Close()
+
+	v, err := redis.Int64(conn.Do("LLEN", key))
+	if err != nil {
+		panic("could not get list length: " + err.Error())
+	}
+	return v
+}
+
+func TestWebUIEnqueueMaxJSONDepth(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithEnqueueAllowlist("wat"), WithMaxJSONDepth(3))
+
+	enqueue := func(body string) int {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/enqueue/wat", strings.NewReader(body))
+		s.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	assert.Equal(t, 200, enqueue(`{"a": {"b": [1, "]]]]"]}}`))
+	assert.Equal(t, 400, enqueue(`{"a": {"b": [[1]]}}`))
+	assert.Equal(t, 400, enqueue(`{"a": `+strings.Repeat("[", 10000)+strings.Repeat("]", 10000)+`}`))
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:wat"))
+}
+
+func TestWebUIEnqueueMaxJSONBodySize(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithEnqueueAllowlist("wat"), WithMaxJSONBodySize(16))
+
+	enqueue := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/enqueue/wat", strings.NewReader(body))
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, 200, enqueue(`{"a": "1234567"}`).Code)
+	recorder := enqueue(`{"a": "12345678"}`)
+	assert.Equal(t, 400, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "larger than 16 bytes")
+	assert.Equal(t, 400, enqueue(`{"a": "`+strings.Repeat("x", 10<<20)+`"}`).Code)
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:wat"))
+}
+
+func TestWebUIEnqueueMalformedBody(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithEnqueueAllowlist("wat"))
+
+	for _, body := range []string{`{"a":`, `[1, 2]`} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/enqueue/wat", strings.NewReader(body))
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 400, recorder.Code, body)
+		assert.Contains(t, recorder.Body.String(), "invalid request body")
+	}
+	assert.EqualValues(t, 0, listSize(pool, ns+":jobs:wat"))
+}
+
+func TestJSONDepth(t *testing.T) {
+	assert.Equal(t, 0, jsonDepth([]byte(`"{["`), 10))
+	assert.Equal(t, 1, jsonDepth([]byte(`{"a": "\"{"}`), 10))
+	assert.Equal(t, 2, jsonDepth([]byte(`[{}, {}, []]`), 10))
+	assert.Equal(t, 3, jsonDepth([]byte(`[[[[[[]]]]]]`), 2))
+}
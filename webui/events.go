@@ -0,0 +1,144 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+// deadJobEventsPath is the route of the dead job event stream.
+const deadJobEventsPath = "/events/dead_jobs"
+
+// deadJobEventBuffer is how many events a subscriber can fall behind by before further events are dropped for it.
+const deadJobEventBuffer = 100
+
+// deadJobEvents fans out the dead jobs that appear between samples of the dead set to the /events/dead_jobs streams.
+// The dead set is only sampled while there are subscribers, and the first sample after that just records what's there,
+// so that subscribers only hear about jobs that die after they subscribed.
+type deadJobEvents struct {
+	mtx         sync.Mutex
+	seen        map[string]bool // the dead jobs at the last sample, by died at and ID; nil until the first
+	subscribers map[chan *work.DeadJob]bool
+	stopped     bool
+}
+
+// sampleDeadJobEvents is run by the sampler to publish the dead jobs that appeared since the last sample.
+func (w *Server) sampleDeadJobEvents() {
+	if !w.deadEvents.subscribed() {
+		return
+	}
+
+	jobs, err := w.readClient.AllDeadJobs()
+	if err != nil {
+		return
+	}
+	w.deadEvents.observe(jobs)
+}
+
+// subscribe returns a channel that receives the dead jobs found from the next sample on. The channel is closed when
+// the server stops.
+func (e *deadJobEvents) subscribe() chan *work.DeadJob {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	ch := make(chan *work.DeadJob, deadJobEventBuffer)
+	if e.stopped {
+		close(ch)
+		return ch
+	}
+	if e.subscribers == nil {
+		e.subscribers = map[chan *work.DeadJob]bool{}
+	}
+	e.subscribers[ch] = true
+	return ch
+}
+
+func (e *deadJobEvents) unsubscribe(ch chan *work.DeadJob) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	delete(e.subscribers, ch)
+	if len(e.subscribers) == 0 {
+		// Start afresh with the next subscriber, rather than replaying what died in between
+		e.seen = nil
+	}
+}
+
+func (e *deadJobEvents) subscribed() bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	return len(e.subscribers) > 0
+}
+
+// observe records a sample of the dead set, and publishes the jobs that weren't in the last one. A subscriber that has
+// fallen too far behind misses the events rather than holding up the others.
+func (e *deadJobEvents) observe(jobs []*work.DeadJob) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	seen := make(map[string]bool, len(jobs))
+	for _, j := range jobs {
+		key := fmt.Sprintf("%d:%s", j.DiedAt, j.ID)
+		seen[key] = true
+		if e.seen == nil || e.seen[key] {
+			continue
+		}
+		for ch := range e.subscribers {
+			select {
+			case ch <- j:
+			default:
+			}
+		}
+	}
+	e.seen = seen
+}
+
+// stop closes the subscribers' channels, so that their streams end and the server can shut down.
+func (e *deadJobEvents) stop() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	for ch := range e.subscribers {
+		close(ch)
+	}
+	e.subscribers = nil
+	e.seen = nil
+	e.stopped = true
+}
+
+// deadJobEventStream streams the jobs that die from now on as server-sent events named dead_job, whose data is the
+// dead job's JSON. Jobs are found by the sampler diffing the dead set, so they arrive up to a sampler interval late,
+// and the dead jobs from before the request aren't replayed. The stream lasts until the client disconnects or the
+// server stops, so it doesn't count towards WithMaxInFlight's limit or the redis pool admission, and can't be batched.
+func (c *context) deadJobEventStream(rw web.ResponseWriter, r *web.Request) {
+	ch := c.deadEvents.subscribe()
+	defer c.deadEvents.unsubscribe(ch)
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(200)
+	// A comment, so that the client knows it's subscribed before the first event
+	fmt.Fprint(rw, ": subscribed\n\n")
+	rw.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case job, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(job)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(rw, "event: dead_job\ndata: %s\n\n", data)
+			rw.Flush()
+		}
+	}
+}
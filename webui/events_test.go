@@ -0,0 +1,129 @@
+package webui
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadJobEventsObserve(t *testing.T) {
+	old := &work.DeadJob{DiedAt: 10, Job: &work.Job{Name: "wat", ID: "a"}}
+	died := &work.DeadJob{DiedAt: 20, Job: &work.Job{Name: "wat", ID: "b"}}
+	diedAgain := &work.DeadJob{DiedAt: 30, Job: &work.Job{Name: "wat", ID: "a"}}
+
+	var e deadJobEvents
+	ch := e.subscribe()
+
+	// The first sample only records what's already dead
+	e.observe([]*work.DeadJob{old})
+	e.observe([]*work.DeadJob{old, died})
+	// A job that was retried and died again is new too
+	e.observe([]*work.DeadJob{died, diedAgain})
+	assert.Equal(t, died, <-ch)
+	assert.Equal(t, diedAgain, <-ch)
+	assert.Len(t, ch, 0)
+
+	// Once nobody's listening, the next subscriber starts afresh
+	e.unsubscribe(ch)
+	assert.Nil(t, e.seen)
+
+	ch = e.subscribe()
+	e.stop()
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestWebUIDeadJobEventStream(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertDeadJob(ns, pool, "wat", 1, 10)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+	ts := httptest.NewServer(s.router)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/events/dead_jobs")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	lines := bufio.NewReader(res.Body)
+	readEvent := func() string {
+		var event string
+		for {
+			line, err := lines.ReadString('\n')
+			if err != nil || line == "\n" {
+				return event
+			}
+			event += line
+		}
+	}
+	assert.Equal(t, ": subscribed\n", readEvent())
+
+	// The backlog isn't replayed, only the job that died after subscribing
+	s.sampleDeadJobEvents()
+	insertDeadJob(ns, pool, "foo", 2, 20)
+	s.sampleDeadJobEvents()
+
+	event := readEvent()
+	assert.True(t, strings.HasPrefix(event, "event: dead_job\ndata: "), event)
+	var job work.DeadJob
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event, "event: dead_job\ndata: ")), &job))
+	assert.Equal(t, "foo-20", job.ID)
+	assert.EqualValues(t, 20, job.DiedAt)
+
+	// Disconnecting unsubscribes
+	res.Body.Close()
+	for i := 0; i < 100 && s.deadEvents.subscribed(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, s.deadEvents.subscribed())
+}
+
+func TestWebUIDeadJobEventStreamAdmission(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithMaxInFlight(1, 10*time.Millisecond), WithAcquireTimeout(10*time.Millisecond))
+	ts := httptest.NewServer(s.router)
+	defer ts.Close()
+
+	// More streams than there are slots of either limit
+	for i := 0; i < pool.MaxActive+1; i++ {
+		res, err := http.Get(ts.URL + "/events/dead_jobs")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer res.Body.Close()
+		assert.Equal(t, 200, res.StatusCode)
+		line, err := bufio.NewReader(res.Body).ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, ": subscribed\n", line)
+	}
+
+	// They don't hold up other requests
+	res, err := http.Get(ts.URL + "/queues")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, 200, res.StatusCode)
+	}
+
+	// And can't be run in a batch, where they'd never return
+	res, err = http.Post(ts.URL+"/batch", "application/json", strings.NewReader(`[{"path": "/events/dead_jobs/"}]`))
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, 400, res.StatusCode)
+	}
+}
@@ -0,0 +1,73 @@
+package webui
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+// failingJob is a job from either the retry or dead set. State says which.
+type failingJob struct {
+	State   string `json:"state"`
+	RetryAt int64  `json:"retry_at,omitempty"`
+	DiedAt  int64  `json:"died_at,omitempty"`
+	*work.Job
+	Args json.RawMessage `json:"args"`
+}
+
+// failingJobs lists the retry and dead sets together, most recent failure first.
+func (c *context) failingJobs(rw web.ResponseWriter, r *web.Request) {
+	page, pageSize, err := parsePage(rw, r, c.config.maxPageSize)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	truncate, err := parseTruncateArgs(r)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	retryJobs, err := c.readClient.AllRetryJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	deadJobs, err := c.readClient.AllDeadJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	jobs := make([]*failingJob, 0, len(retryJobs)+len(deadJobs))
+	for _, j := range retryJobs {
+		jobs = append(jobs, &failingJob{State: "retry", RetryAt: j.RetryAt, Job: j.Job})
+	}
+	for _, j := range deadJobs {
+		jobs = append(jobs, &failingJob{State: "dead", DiedAt: j.DiedAt, Job: j.Job})
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].FailedAt > jobs[j].FailedAt
+	})
+
+	start, end := pageBounds(len(jobs), page, pageSize)
+
+	response := struct {
+		Count int64         `json:"count"`
+		Jobs  []*failingJob `json:"jobs"`
+	}{Count: int64(len(jobs)), Jobs: jobs[start:end]}
+
+	for _, j := range response.Jobs {
+		if j.Args, err = listArgs(j.Job, truncate); err != nil {
+			renderError(rw, err)
+			return
+		}
+	}
+
+	render(rw, response, nil)
+}
@@ -0,0 +1,143 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIFailingJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	// 25 jobs total, interleaving failure times between the two sets
+	for i := int64(0); i < 25; i++ {
+		failedAt := 1000 + i
+		if i%2 == 0 {
+			insertDeadJob(ns, pool, "dead", 1, failedAt)
+		} else {
+			insertRetryJob(ns, pool, "retry", failedAt+100, failedAt)
+		}
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	type result struct {
+		Count int64 `json:"count"`
+		Jobs  []struct {
+			State    string `json:"state"`
+			Name     string `json:"name"`
+			FailedAt int64  `json:"failed_at"`
+			RetryAt  int64  `json:"retry_at"`
+			DiedAt   int64  `json:"died_at"`
+		} `json:"jobs"`
+	}
+
+	var res result
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/failing_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 25, res.Count)
+	if assert.Equal(t, 20, len(res.Jobs)) {
+		assert.Equal(t, "dead", res.Jobs[0].State)
+		assert.EqualValues(t, 1024, res.Jobs[0].FailedAt)
+		assert.EqualValues(t, 1024, res.Jobs[0].DiedAt)
+		assert.Equal(t, "retry", res.Jobs[1].State)
+		assert.EqualValues(t, 1023, res.Jobs[1].FailedAt)
+		assert.EqualValues(t, 1123, res.Jobs[1].RetryAt)
+		for i := 1; i < len(res.Jobs); i++ {
+			assert.True(t, res.Jobs[i-1].FailedAt > res.Jobs[i].FailedAt)
+		}
+	}
+
+	res = result{}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/failing_jobs?page=2", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 25, res.Count)
+	if assert.Equal(t, 5, len(res.Jobs)) {
+		assert.EqualValues(t, 1004, res.Jobs[0].FailedAt)
+		assert.EqualValues(t, 1000, res.Jobs[4].FailedAt)
+		assert.Equal(t, "dead", res.Jobs[4].State)
+	}
+}
+
+func TestWebUIRetryAllRetryJobsNow(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":known_jobs", "wat")
+	conn.Close()
+	assert.NoError(t, err)
+
+	for i := int64(0); i < 3; i++ {
+		insertRetryJob(ns, pool, "wat", 99999999999, 1000+i)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	var res struct {
+		Count  int64 `json:"count"`
+		DryRun bool  `json:"dry_run"`
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/retry_all_retry_jobs/now?dry_run=true", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, res.Count)
+	assert.True(t, res.DryRun)
+	assert.EqualValues(t, 0, listSize(pool, ns+":jobs:wat"))
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/retry_all_retry_jobs/now", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, res.Count)
+	assert.False(t, res.DryRun)
+	assert.EqualValues(t, 3, listSize(pool, ns+":jobs:wat"))
+}
+
+func insertRetryJob(ns string, pool *redis.Pool, name string, retryAt, failAt int64) *work.Job {
+	return insertRetryJobWithFails(ns, pool, name, retryAt, failAt, 1)
+}
+
+func insertRetryJobWithFails(ns string, pool *redis.Pool, name string, retryAt, failAt int64, fails int64) *work.Job {
+	job := &work.Job{
+		Name:       name,
+		ID:         fmt.Sprintf("%s-%d", name, failAt),
+		EnqueuedAt: failAt - 10,
+		Fails:      fails,
+		LastErr:    "sorry",
+		FailedAt:   failAt,
+	}
+
+	rawJSON, _ := json.Marshal(job)
+
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("ZADD", ns+":retry", retryAt, rawJSON); err != nil {
+		panic(err.Error())
+	}
+
+	return job
+}
@@ -0,0 +1,38 @@
+package webui
+
+import (
+	"net/http"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/web"
+)
+
+// healthzPath is the path of the health check. It's left out of auth, so that probes needn't have credentials.
+const healthzPath = "/healthz"
+
+// healthz checks that redis is reachable by pinging it through the server's pool, and its read pool if it has one. It
+// responds with a 200 if it is, and a 503 if not, eg for liveness and readiness probes.
+func (c *context) healthz(rw web.ResponseWriter, r *web.Request) {
+	pools := []*redis.Pool{c.pool}
+	if c.config.readPool != nil {
+		pools = append(pools, c.config.readPool)
+	}
+
+	for _, pool := range pools {
+		if err := ping(pool); err != nil {
+			writeError(rw, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+	}
+
+	render(rw, &statusResponse{Status: "ok"}, nil)
+}
+
+// ping sends a PING over a connection from pool.
+func ping(pool *redis.Pool) error {
+	conn := pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PING")
+	return err
+}
@@ -0,0 +1,64 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIHealthz(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/healthz", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"status": "ok", "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+
+	// Nothing listens on port 1
+	unreachable := &redis.Pool{
+		MaxActive: 1,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", "127.0.0.1:1")
+		},
+	}
+	s = NewServer(ns, unreachable, ":6666", "", "")
+	recorder = httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 503, recorder.Code)
+	var res struct {
+		Error string `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.NotEmpty(t, res.Error)
+	// The failed connection went back to the pool
+	assert.Equal(t, 0, unreachable.ActiveCount())
+
+	// And a read pool is checked too
+	s = NewServer(ns, pool, ":6666", "", "", WithReadPool(unreachable))
+	recorder = httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 503, recorder.Code)
+}
+
+func TestWebUIHealthzClientCertAuth(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServer(ns, pool, ":6666", "", "", WithClientCertAuth("billing-service"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/healthz", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/queues", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 401, recorder.Code)
+}
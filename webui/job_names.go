@@ -0,0 +1,107 @@
+package webui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocraft/web"
+)
+
+const (
+	// jobNameCacheTTL is how long the job name set is reused between searches. Autocomplete fires a search per
+	// keystroke, and new job names showing up a few seconds late doesn't matter.
+	jobNameCacheTTL = 5 * time.Second
+
+	defaultJobNameSearchLimit = 10
+)
+
+// jobNameCache holds the sorted job names for a short while so that searches don't hit redis every time.
+type jobNameCache struct {
+	mtx       sync.Mutex
+	names     []string
+	fetchedAt time.Time
+	hits      int64
+	misses    int64
+}
+
+func (jc *jobNameCache) get(fetch func() ([]string, error)) ([]string, error) {
+	jc.mtx.Lock()
+	defer jc.mtx.Unlock()
+
+	if jc.names != nil && time.Since(jc.fetchedAt) < jobNameCacheTTL {
+		jc.hits++
+		return jc.names, nil
+	}
+	jc.misses++
+
+	names, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	jc.names = names
+	jc.fetchedAt = time.Now()
+
+	return names, nil
+}
+
+// stats returns the cache's hit and miss counts, and how many job names it holds.
+func (jc *jobNameCache) stats() *cacheStats {
+	jc.mtx.Lock()
+	defer jc.mtx.Unlock()
+
+	var entries int
+	if time.Since(jc.fetchedAt) < jobNameCacheTTL {
+		entries = len(jc.names)
+	}
+	return &cacheStats{Hits: jc.hits, Misses: jc.misses, Entries: entries}
+}
+
+// clear drops the cached names, so that the next search reads them from redis.
+func (jc *jobNameCache) clear() {
+	jc.mtx.Lock()
+	defer jc.mtx.Unlock()
+
+	jc.names = nil
+}
+
+// searchJobNames returns up to limit of the sorted names that start with prefix.
+func searchJobNames(names []string, prefix string, limit int) []string {
+	matches := []string{}
+	for i := sort.SearchStrings(names, prefix); i < len(names) && len(matches) < limit; i++ {
+		if !strings.HasPrefix(names[i], prefix) {
+			break
+		}
+		matches = append(matches, names[i])
+	}
+	return matches
+}
+
+// searchJobNames returns the job names starting with the prefix param, for autocompletion. The limit param caps the
+// number of names returned and defaults to 10.
+func (c *context) searchJobNames(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	limit := defaultJobNameSearchLimit
+	if limitStr := r.Form.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil {
+			renderError(rw, badParam("limit", err))
+			return
+		}
+		limit = l
+	}
+
+	names, err := c.jobNames.get(c.readClient.JobNames)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	render(rw, searchJobNames(names, r.Form.Get("prefix"), limit), nil)
+}
@@ -0,0 +1,53 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUISearchJobNames(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	for _, name := range []string{"send_email", "send_sms", "send_push", "sync_users", "resize"} {
+		_, err := enqueuer.Enqueue(name, nil)
+		assert.NoError(t, err)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	search := func(query string) []string {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/job_names/search?"+query, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res []string
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		return res
+	}
+
+	assert.Equal(t, []string{"send_email", "send_push", "send_sms"}, search("prefix=send_"))
+	assert.Equal(t, []string{"send_email", "send_push", "send_sms", "sync_users"}, search("prefix=s"))
+	assert.Equal(t, []string{"send_email", "send_push"}, search("prefix=s&limit=2"))
+	assert.Equal(t, []string{}, search("prefix=zzz"))
+	assert.Equal(t, []string{"resize", "send_email"}, search("limit=2"))
+
+	// Names are cached, so a newly enqueued job doesn't show up right away.
+	_, err := enqueuer.Enqueue("send_fax", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"send_email", "send_push", "send_sms"}, search("prefix=send_"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/job_names/search?prefix=s&limit=lots", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+}
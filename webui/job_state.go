@@ -0,0 +1,87 @@
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+type jobState struct {
+	*work.JobState
+	Args json.RawMessage `json:"args"`
+}
+
+// jobStateByID finds a job by ID wherever it currently is, and returns its state along with the job. It 404s if the
+// job isn't queued, in progress, scheduled, retrying, or dead. With the arg_path param, eg "foo.bar", args is only the
+// value at that path, or null if there's none.
+func (c *context) jobStateByID(rw web.ResponseWriter, r *web.Request) {
+	state, err := c.readClient.FindJobState(r.PathParams["job_id"])
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	if state == nil {
+		renderErrorCode(rw, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+
+	args, err := state.ArgsJSON()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	if path := r.URL.Query().Get("arg_path"); path != "" {
+		args = argsAtPath(args, path)
+	}
+
+	render(rw, &jobState{JobState: state, Args: args}, nil)
+}
+
+// jobWorker is the worker processing a job, and the worker pool it belongs to.
+type jobWorker struct {
+	*work.WorkerObservation
+	WorkerPoolID string `json:"worker_pool_id"`
+	Host         string `json:"host"`
+	Pid          int    `json:"pid"`
+}
+
+// jobWorker returns the worker that's processing the job with the job_id param, found from the busy workers'
+// observations. It 404s if no worker is processing the job.
+func (c *context) jobWorker(rw web.ResponseWriter, r *web.Request) {
+	jobID := r.PathParams["job_id"]
+
+	heartbeats, err := c.readClient.WorkerPoolHeartbeats()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	observations, err := c.readClient.WorkerObservations()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	for _, ob := range observations {
+		if !ob.IsBusy || ob.JobID != jobID {
+			continue
+		}
+
+		response := &jobWorker{WorkerObservation: ob}
+		for _, hb := range heartbeats {
+			for _, workerID := range hb.WorkerIDs {
+				if workerID == ob.WorkerID {
+					response.WorkerPoolID = hb.WorkerPoolID
+					response.Host = hb.Host
+					response.Pid = hb.Pid
+				}
+			}
+		}
+		render(rw, response, nil)
+		return
+	}
+
+	renderErrorCode(rw, http.StatusNotFound, errors.New("job isn't being processed"))
+}
@@ -0,0 +1,142 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIJobState(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	scheduled, err := enqueuer.EnqueueIn("wat", 100, work.Q{"a": 1})
+	assert.NoError(t, err)
+	dead := insertDeadJob(ns, pool, "wat", 12340, 12346)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	type result struct {
+		State      string                 `json:"state"`
+		ID         string                 `json:"id"`
+		Name       string                 `json:"name"`
+		RunAt      int64                  `json:"run_at"`
+		DiedAt     int64                  `json:"died_at"`
+		Args       map[string]interface{} `json:"args"`
+		EnqueuedAt int64                  `json:"t"`
+	}
+
+	var res result
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/job/"+scheduled.ID+"/state", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "scheduled", res.State)
+	assert.Equal(t, scheduled.ID, res.ID)
+	assert.Equal(t, scheduled.RunAt, res.RunAt)
+	assert.EqualValues(t, 1, res.Args["a"])
+
+	res = result{}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/job/"+dead.ID+"/state", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "dead", res.State)
+	assert.EqualValues(t, 12346, res.DiedAt)
+	assert.EqualValues(t, 12340, res.EnqueuedAt)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/job/nope/state", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+}
+
+func TestWebUIJobStateArgPath(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	job, err := enqueuer.Enqueue("wat", work.Q{"user": map[string]interface{}{"address": map[string]interface{}{"city": "Oslo"}}})
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	getArgs := func(query string) string {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/job/"+job.ID+"/state?"+query, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res struct {
+			Args json.RawMessage `json:"args"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		return string(res.Args)
+	}
+
+	assert.Equal(t, `"Oslo"`, getArgs("arg_path=user.address.city"))
+	assert.JSONEq(t, `{"city":"Oslo"}`, getArgs("arg_path=user.address"))
+	assert.Equal(t, `null`, getArgs("arg_path=user.phone"))
+	assert.JSONEq(t, `{"user":{"address":{"city":"Oslo"}}}`, getArgs(""))
+}
+
+func TestWebUIJobWorker(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	defer conn.Close()
+	for _, cmd := range [][]interface{}{
+		{"SADD", ns + ":worker_pools", "pool-1", "pool-2"},
+		{"HMSET", ns + ":worker_pools:pool-1", "heartbeat_at", 1425263409, "worker_ids", "w1,w2", "host", "box-1", "pid", 123},
+		{"HMSET", ns + ":worker_pools:pool-2", "heartbeat_at", 1425263409, "worker_ids", "w3", "host", "box-2", "pid", 456},
+		{"HMSET", ns + ":worker:w2", "job_name", "wat", "job_id", "stuck-job", "started_at", 1425263400},
+		{"HMSET", ns + ":worker:w3", "job_name", "wat", "job_id", "other-job", "started_at", 1425263401},
+	} {
+		_, err := conn.Do(cmd[0].(string), cmd[1:]...)
+		assert.NoError(t, err)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/job/stuck-job/worker", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		WorkerID     string `json:"worker_id"`
+		WorkerPoolID string `json:"worker_pool_id"`
+		Host         string `json:"host"`
+		Pid          int    `json:"pid"`
+		JobName      string `json:"job_name"`
+		JobID        string `json:"job_id"`
+		StartedAt    int64  `json:"started_at"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Equal(t, "w2", res.WorkerID)
+	assert.Equal(t, "pool-1", res.WorkerPoolID)
+	assert.Equal(t, "box-1", res.Host)
+	assert.Equal(t, 123, res.Pid)
+	assert.Equal(t, "wat", res.JobName)
+	assert.Equal(t, "stuck-job", res.JobID)
+	assert.EqualValues(t, 1425263400, res.StartedAt)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/job/done-job/worker", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+}
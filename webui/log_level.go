@@ -0,0 +1,68 @@
+package webui
+
+import (
+	"sync/atomic"
+
+	"github.com/gocraft/web"
+)
+
+// logLevel is how verbose the request log is. Each level includes the ones before it.
+type logLevel int32
+
+const (
+	// logLevelError only logs requests that failed with a 5xx.
+	logLevelError logLevel = iota
+	// logLevelInfo logs every request.
+	logLevelInfo
+	// logLevelDebug also logs each request's query string and user agent.
+	logLevelDebug
+)
+
+var logLevelNames = []string{"error", "info", "debug"}
+
+func (l logLevel) String() string {
+	return logLevelNames[l]
+}
+
+func parseLogLevel(name string) (logLevel, bool) {
+	for i, n := range logLevelNames {
+		if n == name {
+			return logLevel(i), true
+		}
+	}
+	return 0, false
+}
+
+// enabled is whether entries at level are logged at the logger's current level.
+func (l *requestLogger) enabled(level logLevel) bool {
+	return level <= logLevel(atomic.LoadInt32(&l.level))
+}
+
+type logLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// logLevel returns the request log's current level. It's only served with WithRequestLog.
+func (c *context) logLevel(rw web.ResponseWriter, r *web.Request) {
+	level := logLevel(atomic.LoadInt32(&c.config.requestLog.level))
+	render(rw, &logLevelResponse{Level: level.String()}, nil)
+}
+
+// setLogLevel changes the request log's level to the level param, one of error, info or debug. It takes effect right
+// away, including for the entry of this request. It's only served with WithRequestLog.
+func (c *context) setLogLevel(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	level, ok := parseLogLevel(r.Form.Get("level"))
+	if !ok {
+		renderError(rw, badRequestError("level must be one of error, info or debug"))
+		return
+	}
+
+	atomic.StoreInt32(&c.config.requestLog.level, int32(level))
+
+	render(rw, &logLevelResponse{Level: level.String()}, nil)
+}
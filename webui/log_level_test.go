@@ -0,0 +1,87 @@
+package webui
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUILogLevel(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	var buf bytes.Buffer
+	s := NewServer(ns, pool, ":6666", "", "", WithRequestLog(&buf, RequestLogText))
+	s.router.Get("/fail", func(c *context, rw web.ResponseWriter, r *web.Request) {
+		renderError(rw, errors.New("redis is down"))
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest(method, path, nil)
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	setLevel := func(level string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/log_level", strings.NewReader("level="+level))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	logged := func() []string {
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		buf.Reset()
+		return lines
+	}
+
+	recorder := serve("GET", "/log_level")
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"level": "info", "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+
+	// At info, requests are logged without their details
+	serve("GET", "/queues?page=2")
+	lines := logged()
+	assert.Equal(t, 2, len(lines))
+	assert.True(t, strings.HasPrefix(lines[1], "INFO: webui.request - GET /queues 200 "), lines[1])
+
+	recorder = setLevel("debug")
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"level": "debug", "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+	logged()
+
+	serve("GET", "/queues?page=2")
+	lines = logged()
+	if assert.Equal(t, 2, len(lines)) {
+		assert.True(t, strings.HasPrefix(lines[0], `DEBUG: webui.request - GET /queues query="page=2" `), lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], "INFO: webui.request - GET /queues 200 "), lines[1])
+	}
+
+	// At error, only requests that failed on the server's side are logged
+	setLevel("error")
+	logged()
+	serve("GET", "/queues")
+	serve("GET", "/retry_jobs?page=nope")
+	assert.Equal(t, "", buf.String())
+	serve("GET", "/fail")
+	lines = logged()
+	if assert.Equal(t, 1, len(lines)) {
+		assert.True(t, strings.HasPrefix(lines[0], "ERROR: webui.request - GET /fail 500 "), lines[0])
+	}
+
+	recorder = setLevel("verbose")
+	assert.Equal(t, 400, recorder.Code)
+	assert.JSONEq(t, `{"level": "error", "meta": {"namespace": "testwork"}}`, serve("GET", "/log_level").Body.String())
+
+	// Without a request log there's no level to change
+	s = NewServer(ns, pool, ":6666", "", "")
+	recorder = serve("GET", "/log_level")
+	assert.Equal(t, 404, recorder.Code)
+}
@@ -0,0 +1,60 @@
+package webui
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/gocraft/web"
+)
+
+// namespaceHeader is the response header that carries the server's work namespace.
+const namespaceHeader = "X-Work-Namespace"
+
+// meta describes which work namespace a response is about, so that operators juggling several namespaces can tell
+// them apart. render adds it to every JSON object response that's built from a struct; lists, and maps keyed by data
+// such as job names, can't carry it without changing shape, so they only have the X-Work-Namespace header.
+type meta struct {
+	Namespace string `json:"namespace"`
+}
+
+// setNamespace echoes the server's namespace in the response headers, where render picks it up for the meta field.
+func (c *context) setNamespace(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	rw.Header().Set(namespaceHeader, c.namespace)
+	next(rw, r)
+}
+
+// hasMeta is whether render adds meta to the JSON of jsonable: whether it's a struct, or a pointer to one.
+func hasMeta(jsonable interface{}) bool {
+	v := reflect.ValueOf(jsonable)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Struct
+}
+
+// withMeta adds a meta field for namespace to data, the indented JSON of a struct. Data that isn't an object, eg
+// from a struct with its own MarshalJSON, is left as it is.
+func withMeta(data []byte, namespace string) []byte {
+	if len(data) < 2 || data[0] != '{' || data[len(data)-1] != '}' {
+		return data
+	}
+	m, err := json.MarshalIndent(meta{Namespace: namespace}, "\t", "\t")
+	if err != nil {
+		return data
+	}
+
+	var b bytes.Buffer
+	fields := bytes.TrimSuffix(data[:len(data)-1], []byte("\n"))
+	b.Write(fields)
+	if len(fields) > 1 {
+		b.WriteByte(',')
+	}
+	b.WriteString("\n\t\"meta\": ")
+	b.Write(m)
+	b.WriteString("\n}")
+	return b.Bytes()
+}
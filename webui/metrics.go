@@ -0,0 +1,108 @@
+package webui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gocraft/web"
+)
+
+const (
+	prometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// gauge is a single metric family. Samples are keyed by job name; an empty job name means the sample has no labels.
+type gauge struct {
+	name    string
+	help    string
+	samples []gaugeSample
+}
+
+type gaugeSample struct {
+	jobName string
+	value   int64
+}
+
+// metrics exposes queue and job set sizes for scraping. The output is in the Prometheus text format unless the client asks for OpenMetrics in its Accept header.
+func (c *context) metrics(rw web.ResponseWriter, r *web.Request) {
+	gauges, err := c.gauges()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+
+	var buf bytes.Buffer
+	for _, g := range gauges {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", g.name)
+		for _, s := range g.samples {
+			if s.jobName == "" {
+				fmt.Fprintf(&buf, "%s %d\n", g.name, s.value)
+			} else {
+				fmt.Fprintf(&buf, "%s{job_name=%q} %d\n", g.name, s.jobName, s.value)
+			}
+		}
+	}
+
+	if openMetrics {
+		buf.WriteString("# EOF\n")
+		rw.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		rw.Header().Set("Content-Type", prometheusContentType)
+	}
+	rw.Write(buf.Bytes())
+}
+
+func (c *context) gauges() ([]*gauge, error) {
+	queues, err := c.readClient.Queues()
+	if err != nil {
+		return nil, err
+	}
+
+	queueSize := &gauge{name: "work_queue_jobs", help: "Number of jobs waiting in the queue."}
+	queueLatency := &gauge{name: "work_queue_latency_seconds", help: "Age of the oldest job waiting in the queue."}
+	for _, q := range queues {
+		queueSize.samples = append(queueSize.samples, gaugeSample{jobName: q.JobName, value: q.Count})
+		queueLatency.samples = append(queueLatency.samples, gaugeSample{jobName: q.JobName, value: q.Latency})
+	}
+
+	_, retryCount, err := c.readClient.RetryJobs(1)
+	if err != nil {
+		return nil, err
+	}
+
+	_, scheduledCount, err := c.readClient.ScheduledJobs(1)
+	if err != nil {
+		return nil, err
+	}
+
+	_, deadCount, err := c.readClient.DeadJobs(1)
+	if err != nil {
+		return nil, err
+	}
+
+	observations, err := c.readClient.WorkerObservations()
+	if err != nil {
+		return nil, err
+	}
+
+	var busyCount int64
+	for _, ob := range observations {
+		if ob.IsBusy {
+			busyCount++
+		}
+	}
+
+	return []*gauge{
+		queueSize,
+		queueLatency,
+		{name: "work_retry_jobs", help: "Number of jobs waiting to be retried.", samples: []gaugeSample{{value: retryCount}}},
+		{name: "work_scheduled_jobs", help: "Number of jobs scheduled to run in the future.", samples: []gaugeSample{{value: scheduledCount}}},
+		{name: "work_dead_jobs", help: "Number of jobs that exhausted their retries.", samples: []gaugeSample{{value: deadCount}}},
+		{name: "work_busy_workers", help: "Number of workers currently processing a job.", samples: []gaugeSample{{value: busyCount}}},
+	}, nil
+}
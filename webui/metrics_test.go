@@ -0,0 +1,45 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIMetricsFormat(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	_, err := enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+	insertDeadJob(ns, pool, "dead", 1, 5)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, prometheusContentType, recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	assert.Contains(t, body, "# TYPE work_queue_jobs gauge\n")
+	assert.Contains(t, body, "work_queue_jobs{job_name=\"wat\"} 1\n")
+	assert.Contains(t, body, "work_dead_jobs 1\n")
+	assert.False(t, strings.Contains(body, "# EOF"))
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/metrics", nil)
+	request.Header.Set("Accept", "application/openmetrics-text; version=1.0.0,text/plain;q=0.5")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, openMetricsContentType, recorder.Header().Get("Content-Type"))
+	body = recorder.Body.String()
+	assert.Contains(t, body, "work_queue_jobs{job_name=\"wat\"} 1\n")
+	assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+}
@@ -0,0 +1,170 @@
+package webui
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocraft/web"
+)
+
+// requestIDHeader is the response header that carries the ID generated for each request.
+const requestIDHeader = "X-Request-ID"
+
+// assignRequestID generates an ID for the request and echoes it in the response headers, so that responses can be
+// matched with log entries.
+func (c *context) assignRequestID(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	c.requestID = makeRandomID()
+	rw.Header().Set(requestIDHeader, c.requestID)
+	next(rw, r)
+}
+
+// recoverPanic turns a panic in a later middleware or handler into a JSON 500 that includes the request ID, and logs
+// the panic and its stack trace along with the same ID, to the request log if there is one.
+func (c *context) recoverPanic(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			const size = 4096
+			stack := make([]byte, size)
+			stack = stack[:runtime.Stack(stack, false)]
+			if l := c.config.requestLog; l != nil {
+				l.writePanic(&requestPanicEntry{
+					Level:     logLevelError.String(),
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					Panic:     fmt.Sprint(recovered),
+					Stack:     string(stack),
+					RequestID: c.requestID,
+				})
+			} else {
+				logError("webui.panic", fmt.Errorf("request_id=%s %s %s - %v\n%s", c.requestID, r.Method, r.URL.Path, recovered, stack))
+			}
+
+			rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+			rw.WriteHeader(500)
+			render(rw, map[string]string{"error": "internal server error", "request_id": c.requestID}, nil)
+		}
+	}()
+
+	next(rw, r)
+}
+
+// admission limits how many requests are handled at once. Requests beyond the limit wait up to timeout for a slot, and
+// are turned away with busyError if none frees up.
+type admission struct {
+	slots     chan struct{}
+	timeout   time.Duration
+	busyError string
+}
+
+func newAdmission(size int, timeout time.Duration, busyError string) *admission {
+	return &admission{slots: make(chan struct{}, size), timeout: timeout, busyError: busyError}
+}
+
+// admit waits up to the admission's timeout for a slot. If none frees up in time, it responds with a 503 and a
+// Retry-After instead of calling next.
+func (a *admission) admit(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	select {
+	case a.slots <- struct{}{}:
+	default:
+		// Only start a timer when there's no free slot, so that a zero timeout doesn't race a free slot
+		timer := time.NewTimer(a.timeout)
+		defer timer.Stop()
+
+		select {
+		case a.slots <- struct{}{}:
+		case <-timer.C:
+			renderBusy(rw, a.timeout, a.busyError)
+			return
+		}
+	}
+	defer func() { <-a.slots }()
+
+	next(rw, r)
+}
+
+// routePath is the path a request for rawPath is routed by: without its query, cleaned, and without a trailing
+// slash, which gocraft/web ignores.
+func routePath(rawPath string) string {
+	u, err := url.Parse(rawPath)
+	if err != nil {
+		return rawPath
+	}
+	return path.Clean(u.Path)
+}
+
+// isLongLived returns whether r is for one of the routes that hold their request open for a while: the dead job event
+// stream, which lasts until the client disconnects, and retry_sync, which waits for a worker to pick up its job.
+func isLongLived(r *web.Request) bool {
+	p := routePath(r.URL.Path)
+	return p == deadJobEventsPath || (strings.HasPrefix(p, "/dead_job/") && strings.HasSuffix(p, "/retry_sync"))
+}
+
+// admitRequest limits the requests handled at once to the redis pool's MaxActive, so that when the pool is busy
+// requests are turned away quickly instead of piling up waiting for a connection. Batch sub-requests run in their batch
+// request's slot, and long-lived requests don't take a slot, so that they can't starve the others.
+func (c *context) admitRequest(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	if isBatchSubRequest(r) || isLongLived(r) {
+		next(rw, r)
+		return
+	}
+	c.admission.admit(rw, r, next)
+}
+
+// limitInFlight limits the requests handled at once to the max given to WithMaxInFlight, whatever they're waiting on.
+// Batch sub-requests run in their batch request's slot, and long-lived requests don't take a slot.
+func (c *context) limitInFlight(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	if isBatchSubRequest(r) || isLongLived(r) {
+		next(rw, r)
+		return
+	}
+	c.inFlight.admit(rw, r, next)
+}
+
+// prefixHandler serves the requests for paths under prefix with h, with the prefix stripped. prefix itself redirects
+// to prefix + "/", and anything else is a 404.
+func prefixHandler(prefix string, h http.Handler) http.Handler {
+	stripped := http.StripPrefix(prefix, h)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == prefix:
+			http.Redirect(rw, r, prefix+"/", http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, prefix+"/"):
+			stripped.ServeHTTP(rw, r)
+		default:
+			http.NotFound(rw, r)
+		}
+	})
+}
+
+// renderUnavailable responds that redis is too busy, suggesting the client retry after about retryAfter.
+func renderUnavailable(rw http.ResponseWriter, retryAfter time.Duration) {
+	renderBusy(rw, retryAfter, "redis connection pool exhausted")
+}
+
+// renderBusy responds with a 503 and the error message, suggesting the client retry after about retryAfter.
+func renderBusy(rw http.ResponseWriter, retryAfter time.Duration, message string) {
+	secs := int64((retryAfter + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	rw.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	writeError(rw, http.StatusServiceUnavailable, message)
+}
+
+// makeRandomID returns a random hex ID, eg for requests and saved views.
+func makeRandomID() string {
+	b := make([]byte, 12)
+	_, err := io.ReadFull(rand.Reader, b)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,186 @@
+package webui
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocraft/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIRequestID(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	ids := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/uptime", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		id := recorder.Header().Get("X-Request-ID")
+		assert.Equal(t, 24, len(id))
+		ids[id] = true
+	}
+	assert.Equal(t, 3, len(ids))
+
+	// Even on responses that don't come from a handler
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/nope", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+	assert.NotEqual(t, "", recorder.Header().Get("X-Request-ID"))
+}
+
+func TestWebUIRecoverPanic(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServer(ns, pool, ":6666", "", "")
+	s.router.Get("/panic", func(c *context, rw web.ResponseWriter, r *web.Request) {
+		panic("ohno")
+	})
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/panic", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 500, recorder.Code)
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	var res struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "internal server error", res.Error)
+	assert.NotEqual(t, "", res.RequestID)
+	assert.Equal(t, recorder.Header().Get("X-Request-ID"), res.RequestID)
+}
+
+func TestWebUIRecoverPanicRequestLog(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	var buf bytes.Buffer
+	s := NewServer(ns, pool, ":6666", "", "", WithRequestLog(&buf, RequestLogJSON))
+	s.router.Get("/panic", func(c *context, rw web.ResponseWriter, r *web.Request) {
+		panic("ohno")
+	})
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/panic", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 500, recorder.Code)
+
+	// The panic is logged first, then the request it failed
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Equal(t, 2, len(lines)) {
+		var entry requestPanicEntry
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		assert.Equal(t, "error", entry.Level)
+		assert.Equal(t, "GET", entry.Method)
+		assert.Equal(t, "/panic", entry.Path)
+		assert.Equal(t, "ohno", entry.Panic)
+		assert.Contains(t, entry.Stack, "goroutine")
+		assert.Equal(t, recorder.Header().Get("X-Request-ID"), entry.RequestID)
+
+		var logged requestLogEntry
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &logged))
+		assert.Equal(t, 500, logged.Status)
+		assert.Equal(t, entry.RequestID, logged.RequestID)
+	}
+}
+
+func TestWebUIAcquireTimeout(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "", WithAcquireTimeout(10*time.Millisecond))
+
+	// Every slot is taken by in-flight requests
+	for i := 0; i < pool.MaxActive; i++ {
+		s.admission.slots <- struct{}{}
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/queues", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 503, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	<-s.admission.slots
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/queues", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, pool.MaxActive-1, len(s.admission.slots))
+}
+
+func TestWebUIMaxInFlight(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "", WithMaxInFlight(2, 0))
+
+	// Both slots are taken by in-flight requests
+	s.inFlight.slots <- struct{}{}
+	s.inFlight.slots <- struct{}{}
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/queues", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 503, recorder.Code)
+		assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+		assert.Contains(t, recorder.Body.String(), "too many requests in flight")
+	}
+
+	<-s.inFlight.slots
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/queues", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, 1, len(s.inFlight.slots))
+
+	// With a wait, excess requests queue up for a slot
+	s = NewServer(ns, pool, ":6666", "", "", WithMaxInFlight(1, time.Second))
+	s.inFlight.slots <- struct{}{}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-s.inFlight.slots
+	}()
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/queues", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, 0, len(s.inFlight.slots))
+}
+
+func TestWebUIPoolExhausted(t *testing.T) {
+	pool := newTestPool(":6379")
+	pool.MaxActive = 1
+	pool.Wait = false
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	conn := pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("PING")
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/queues", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 503, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+}
@@ -0,0 +1,48 @@
+package webui
+
+import (
+	"sort"
+
+	"github.com/gocraft/web"
+)
+
+// queueConcurrency is how many of a queue's jobs can be processed at once across the namespace.
+type queueConcurrency struct {
+	JobName     string `json:"job_name"`
+	Concurrency uint   `json:"concurrency"`
+	WorkerPools int    `json:"worker_pools"`
+}
+
+// namespaceConcurrency sums the configured concurrency of the worker pools serving each queue, from their heartbeats.
+// A pool's workers share its concurrency between all of its queues, so each queue gets the pool's full concurrency:
+// the sum is the most jobs of that queue that can run at once, not a share of the workers.
+func (c *context) namespaceConcurrency(rw web.ResponseWriter, r *web.Request) {
+	heartbeats, err := c.readClient.WorkerPoolHeartbeats()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	byJobName := map[string]*queueConcurrency{}
+	for _, hb := range heartbeats {
+		for _, jobName := range hb.JobNames {
+			qc, ok := byJobName[jobName]
+			if !ok {
+				qc = &queueConcurrency{JobName: jobName}
+				byJobName[jobName] = qc
+			}
+			qc.Concurrency += hb.Concurrency
+			qc.WorkerPools++
+		}
+	}
+
+	response := make([]*queueConcurrency, 0, len(byJobName))
+	for _, qc := range byJobName {
+		response = append(response, qc)
+	}
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].JobName < response[j].JobName
+	})
+
+	render(rw, response, nil)
+}
@@ -0,0 +1,48 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUINamespaceConcurrency(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	for id, hb := range map[string][]interface{}{
+		"pool1": {"job_names", "wat,foo", "concurrency", 10},
+		"pool2": {"job_names", "wat", "concurrency", 5},
+		"pool3": {"job_names", "bar,foo,wat", "concurrency", 2},
+	} {
+		_, err := conn.Do("SADD", ns+":worker_pools", id)
+		assert.NoError(t, err)
+		_, err = conn.Do("HMSET", append([]interface{}{ns + ":worker_pools:" + id}, hb...)...)
+		assert.NoError(t, err)
+	}
+	// A pool without a heartbeat serves nothing
+	_, err := conn.Do("SADD", ns+":worker_pools", "gone")
+	assert.NoError(t, err)
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/namespace/concurrency", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res []queueConcurrency
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, []queueConcurrency{
+		{JobName: "bar", Concurrency: 2, WorkerPools: 1},
+		{JobName: "foo", Concurrency: 12, WorkerPools: 2},
+		{JobName: "wat", Concurrency: 17, WorkerPools: 3},
+	}, res)
+}
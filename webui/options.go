@@ -0,0 +1,339 @@
+package webui
+
+import (
+	"crypto/tls"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const (
+	defaultSamplerInterval = 10 * time.Second
+	defaultMaxPageSize     = 100
+)
+
+// Option configures optional behavior of a Server. Options are passed to NewServerWithOptions or NewServer.
+type Option func(*config)
+
+type config struct {
+	username        string
+	password        string
+	bearerToken     string
+	pathPrefix      string
+	tlsCertFile     string
+	tlsKeyFile      string
+	samplerInterval time.Duration
+	errorCategories []ErrorCategory
+	enqueueable     map[string]bool
+	tlsConfig       *tls.Config
+	certSubjects    map[string]bool
+	disableUI       bool
+	rootRedirect    string
+	assetBaseURL    string
+	maxPageSize     uint
+	maxJSONDepth    int
+	maxJSONBodySize int64
+	alertWebhookURL string
+	samplerReset    bool
+	reap            bool
+	requeueInFlight bool
+	deadLetterQueue string
+	requestLog      *requestLogger
+
+	readPool         *redis.Pool
+	retryConcurrency int
+	fanOutSize       int
+	checkConns       bool
+	checkIdleAfter   time.Duration
+	acquireTimeout   time.Duration
+	maxInFlight      int
+	inFlightWait     time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		samplerInterval:  defaultSamplerInterval,
+		retryConcurrency: 1,
+		maxPageSize:      defaultMaxPageSize,
+		maxJSONDepth:     defaultMaxJSONDepth,
+		maxJSONBodySize:  defaultMaxJSONBodySize,
+	}
+}
+
+// WithBasicAuth makes the server require basic auth with username and password, for the HTML UI and the JSON API alike;
+// only the health check is left open. Without it, or with both empty, and without WithBearerToken, everything is served
+// without auth, eg for running behind a reverse proxy that authenticates already or on localhost.
+func WithBasicAuth(username, password string) Option {
+	return func(c *config) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithBearerToken makes the server require requests to the HTML UI and the JSON API to carry token in an
+// "Authorization: Bearer <token>" header, eg as forwarded by a gateway in front of the server. Basic auth set with
+// WithBasicAuth keeps working alongside it.
+func WithBearerToken(token string) Option {
+	return func(c *config) {
+		c.bearerToken = token
+	}
+}
+
+// hasCredentials is whether basic auth credentials or a bearer token are configured.
+func (c *config) hasCredentials() bool {
+	return c.username != "" || c.password != "" || c.bearerToken != ""
+}
+
+// WithPathPrefix serves everything under prefix, eg "/work" to serve /queues at /work/queues, for mounting the server
+// alongside others behind a reverse proxy. Requests for paths outside it are 404s, and prefix itself redirects to
+// prefix + "/". The HTML UI loads its assets from and calls the API under the prefix, which is injected into the page
+// as the workPathPrefix global.
+func WithPathPrefix(prefix string) Option {
+	return func(c *config) {
+		c.pathPrefix = "/" + strings.Trim(prefix, "/")
+		if c.pathPrefix == "/" {
+			c.pathPrefix = ""
+		}
+	}
+}
+
+// WithTLS makes Start serve HTTPS with the certificate and key in the PEM files certFile and keyFile, which are loaded
+// when the server starts. Combined with WithTLSConfig, the certificate is added to that config.
+func WithTLS(certFile, keyFile string) Option {
+	return func(c *config) {
+		c.tlsCertFile = certFile
+		c.tlsKeyFile = keyFile
+	}
+}
+
+// serverTLSConfig returns the tls.Config Start serves HTTPS with, or nil to serve plain HTTP.
+func (c *config) serverTLSConfig() (*tls.Config, error) {
+	if c.tlsCertFile == "" && c.tlsKeyFile == "" {
+		return c.tlsConfig, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.tlsCertFile, c.tlsKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{}
+	if c.tlsConfig != nil {
+		tlsConfig = c.tlsConfig.Clone()
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	return tlsConfig, nil
+}
+
+// WithSamplerInterval sets how often the server's background samplers run. The default is 10 seconds.
+func WithSamplerInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.samplerInterval = interval
+	}
+}
+
+// WithoutUI serves only the JSON API, without the bundled HTML UI at / and /work.js. Instead, / lists the API's
+// endpoints, or redirects if WithRootRedirect is also given. To also leave the UI's assets out of the binary, build
+// with the noui tag, which implies WithoutUI.
+func WithoutUI() Option {
+	return func(c *config) {
+		c.disableUI = true
+	}
+}
+
+// WithRootRedirect makes / redirect to url, eg "/queues", when the UI is disabled with WithoutUI.
+func WithRootRedirect(url string) Option {
+	return func(c *config) {
+		c.rootRedirect = url
+	}
+}
+
+// WithAssetBaseURL makes the HTML UI load its assets, such as work.js, from under baseURL (eg a CDN) instead of from
+// this server.
+func WithAssetBaseURL(baseURL string) Option {
+	return func(c *config) {
+		c.assetBaseURL = baseURL
+	}
+}
+
+// WithMaxPageSize sets the most jobs a page of the job list endpoints can have. Larger page_size params are clamped to
+// it, and the response has an X-Page-Size-Clamped header with the size used. The default is 100.
+func WithMaxPageSize(size uint) Option {
+	return func(c *config) {
+		c.maxPageSize = size
+	}
+}
+
+// WithMaxJSONDepth sets how deeply arrays and objects can be nested in JSON request bodies, such as the args of jobs
+// enqueued through the API. Deeper bodies are rejected with a 400. The default is 32.
+func WithMaxJSONDepth(depth int) Option {
+	return func(c *config) {
+		c.maxJSONDepth = depth
+	}
+}
+
+// WithMaxJSONBodySize sets the largest JSON request body the server reads, in bytes. Larger bodies are rejected with a
+// 400 before they're read in full. The default is 1MiB.
+func WithMaxJSONBodySize(size int64) Option {
+	return func(c *config) {
+		c.maxJSONBodySize = size
+	}
+}
+
+// WithAlertWebhook makes the server's samplers POST a JSON alert to url whenever the number of dead jobs of a job name
+// reaches the threshold set for it with /alert_thresholds.
+func WithAlertWebhook(url string) Option {
+	return func(c *config) {
+		c.alertWebhookURL = url
+	}
+}
+
+// WithSamplerReset serves POST /samplers/reset, which clears everything the background samplers have recorded. It's
+// meant for testing, so it's off by default.
+func WithSamplerReset() Option {
+	return func(c *config) {
+		c.samplerReset = true
+	}
+}
+
+// WithReap serves POST /reap, which removes the worker observations and worker pool IDs left behind by crashed worker
+// pools. It's off by default.
+func WithReap() Option {
+	return func(c *config) {
+		c.reap = true
+	}
+}
+
+// WithRequeueInFlight serves POST /worker_pool/:pool_id/requeue_inflight, which moves the jobs a crashed worker pool
+// had in progress back onto their queues. Without credentials the JSON API is served without auth, so it's off by
+// default.
+func WithRequeueInFlight() Option {
+	return func(c *config) {
+		c.requeueInFlight = true
+	}
+}
+
+// WithDeadLetterQueue serves POST /queue/:queue/to_deadletter, which moves all of a queue's pending jobs to the queue
+// of jobName, eg to drain a queue whose jobs keep failing without losing them. Moved jobs keep their own names, so the
+// dead-letter queue shouldn't be one that workers process. It's off by default.
+func WithDeadLetterQueue(jobName string) Option {
+	return func(c *config) {
+		c.deadLetterQueue = jobName
+	}
+}
+
+// WithRequestLog writes an entry to out for each request served, with its method, path, status, duration, user,
+// request ID and remote IP, in the given format. It logs at the info level, which /log_level can change at runtime.
+func WithRequestLog(out io.Writer, format RequestLogFormat) Option {
+	return func(c *config) {
+		c.requestLog = &requestLogger{out: out, format: format, level: int32(logLevelInfo)}
+	}
+}
+
+// ErrorCategory labels dead jobs whose error matches Pattern.
+type ErrorCategory struct {
+	Pattern *regexp.Regexp
+	Label   string
+}
+
+// WithErrorCategories sets the rules used to group dead jobs by error in /dead_jobs/categories. Each job is labeled by
+// the first rule whose pattern matches its error; jobs matching no rule are grouped by their exact error.
+func WithErrorCategories(categories ...ErrorCategory) Option {
+	return func(c *config) {
+		c.errorCategories = categories
+	}
+}
+
+// WithEnqueueAllowlist restricts the job names that can be enqueued through the API to jobNames. Without an allowlist,
+// only job names known to the namespace's worker pools can be enqueued.
+func WithEnqueueAllowlist(jobNames ...string) Option {
+	return func(c *config) {
+		c.enqueueable = make(map[string]bool, len(jobNames))
+		for _, name := range jobNames {
+			c.enqueueable[name] = true
+		}
+	}
+}
+
+// WithTLSConfig makes Start serve HTTPS using tlsConfig, which must include the server's certificate.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *config) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithClientCertAuth only allows requests presenting a verified TLS client certificate whose subject common name is
+// one of subjects. The server's tls.Config should set ClientAuth to tls.RequireAndVerifyClientCert and ClientCAs to the
+// CAs that issue client certificates. The certificate replaces basic auth for the HTML UI, and requests are logged as
+// made by its subject.
+func WithClientCertAuth(subjects ...string) Option {
+	return func(c *config) {
+		c.certSubjects = make(map[string]bool, len(subjects))
+		for _, s := range subjects {
+			c.certSubjects[s] = true
+		}
+	}
+}
+
+// WithReadPool makes the read-only endpoints, such as the job lists and counts, read from readPool, eg a pool of
+// connections to a redis replica. Anything that modifies jobs still uses the primary pool passed to NewServer, as do
+// the reads it does along the way.
+func WithReadPool(readPool *redis.Pool) Option {
+	return func(c *config) {
+		c.readPool = readPool
+	}
+}
+
+// WithConnectionCheck makes the redis pools ping connections that have been idle for longer than idleAfter before
+// handing them out, so that connections redis has dropped are replaced instead of failing a request. A zero idleAfter
+// checks every connection. It's installed as the pools' TestOnBorrow, and only on pools that don't already have one.
+func WithConnectionCheck(idleAfter time.Duration) Option {
+	return func(c *config) {
+		c.checkConns = true
+		c.checkIdleAfter = idleAfter
+	}
+}
+
+// WithAcquireTimeout makes the server turn requests away with a 503 and a Retry-After header when they'd have to wait
+// longer than timeout for the redis pool, instead of queueing up behind it. At most the pool's MaxActive requests are
+// handled at once; it has no effect on pools without a MaxActive limit. Independently of this option, requests that
+// fail because a pool with Wait unset is exhausted also get a 503.
+func WithAcquireTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.acquireTimeout = timeout
+	}
+}
+
+// WithMaxInFlight limits how many requests the server handles at once to max, to protect redis and the process from
+// load spikes. Requests beyond the limit wait up to wait for another to finish, and are then turned away with a 503
+// and a Retry-After header; a zero wait turns them away right away. Unlike WithAcquireTimeout, it counts every
+// request, whether or not it's waiting on redis, except for long-lived ones such as /events/dead_jobs.
+func WithMaxInFlight(max int, wait time.Duration) Option {
+	return func(c *config) {
+		c.maxInFlight = max
+		c.inFlightWait = wait
+	}
+}
+
+// WithRetryConcurrency sets how many dead jobs are retried in parallel when retrying a filtered set of them, eg with
+// max_fails. The default is 1.
+func WithRetryConcurrency(concurrency int) Option {
+	return func(c *config) {
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		c.retryConcurrency = concurrency
+	}
+}
+
+// WithFanOutSize caps how many redis operations the server runs in parallel across all requests, eg while retrying dead
+// jobs concurrently. The default is the redis pool's MaxActive, or GOMAXPROCS if the pool doesn't limit active
+// connections.
+func WithFanOutSize(size int) Option {
+	return func(c *config) {
+		c.fanOutSize = size
+	}
+}
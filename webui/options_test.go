@@ -0,0 +1,143 @@
+package webui
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewServerWithOptionsPathPrefix(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+	s := NewServerWithOptions(ns, pool, ":6666", WithPathPrefix("/work/"), WithoutUI())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.server.Handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, 200, get("/work/queues").Code)
+	assert.Equal(t, 200, get("/work/").Code)
+	assert.Equal(t, 404, get("/queues").Code)
+	assert.Equal(t, 404, get("/workers").Code)
+
+	recorder := get("/work")
+	assert.Equal(t, 301, recorder.Code)
+	assert.Equal(t, "/work/", recorder.Header().Get("Location"))
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	cfg := defaultConfig()
+	tlsConfig, err := cfg.serverTLSConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	WithTLS(certFile, keyFile)(cfg)
+	tlsConfig, err = cfg.serverTLSConfig()
+	assert.NoError(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+
+	// The certificate is added to a config given with WithTLSConfig, without changing it
+	base := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}
+	WithTLSConfig(base)(cfg)
+	tlsConfig, err = cfg.serverTLSConfig()
+	assert.NoError(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+	assert.Empty(t, base.Certificates)
+
+	WithTLS(certFile, filepath.Join(t.TempDir(), "missing.pem"))(cfg)
+	_, err = cfg.serverTLSConfig()
+	assert.Error(t, err)
+}
+
+func TestWebUIStartTLS(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+	certFile, keyFile := writeTestCert(t)
+
+	// Find a free port to serve on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	s := NewServer(ns, pool, addr, "admin", "secret", WithTLS(certFile, keyFile))
+	if !assert.NoError(t, s.Start()) {
+		return
+	}
+	defer s.Stop()
+
+	certPEM, err := os.ReadFile(certFile)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	for _, path := range []string{"/queues", "/"} {
+		request, _ := http.NewRequest("GET", "https://"+addr+path, nil)
+		request.SetBasicAuth("admin", "secret")
+		res, err := client.Do(request)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		res.Body.Close()
+		assert.Equal(t, 200, res.StatusCode, path)
+		assert.NotNil(t, res.TLS, path)
+	}
+
+	// Plain HTTP is turned away
+	res, err := http.Get("http://" + addr + "/queues")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, 400, res.StatusCode)
+	}
+}
+
+// writeTestCert writes a self-signed certificate and its key to PEM files, and returns their paths.
+func writeTestCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
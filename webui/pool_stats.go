@@ -0,0 +1,57 @@
+package webui
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/web"
+)
+
+type poolStats struct {
+	ActiveCount        int   `json:"active_count"`
+	IdleCount          int   `json:"idle_count"`
+	MaxActive          int   `json:"max_active"`
+	MaxIdle            int   `json:"max_idle"`
+	IdleTimeoutSeconds int64 `json:"idle_timeout_seconds"`
+	Wait               bool  `json:"wait"`
+}
+
+func newPoolStats(pool *redis.Pool) *poolStats {
+	return &poolStats{
+		ActiveCount:        pool.ActiveCount(),
+		IdleCount:          pool.IdleCount(),
+		MaxActive:          pool.MaxActive,
+		MaxIdle:            pool.MaxIdle,
+		IdleTimeoutSeconds: int64(pool.IdleTimeout.Seconds()),
+		Wait:               pool.Wait,
+	}
+}
+
+// poolStats reports the connection counts and limits of the server's redis pool, and of its read pool if it has one.
+func (c *context) poolStats(rw web.ResponseWriter, r *web.Request) {
+	response := struct {
+		Pool     *poolStats `json:"pool"`
+		ReadPool *poolStats `json:"read_pool,omitempty"`
+	}{Pool: newPoolStats(c.pool)}
+
+	if c.config.readPool != nil {
+		response.ReadPool = newPoolStats(c.config.readPool)
+	}
+
+	render(rw, response, nil)
+}
+
+// checkConnections makes pool ping connections that have been idle for longer than idleAfter before handing them out,
+// unless the pool already tests connections itself.
+func checkConnections(pool *redis.Pool, idleAfter time.Duration) {
+	if pool.TestOnBorrow != nil {
+		return
+	}
+	pool.TestOnBorrow = func(conn redis.Conn, lastUsed time.Time) error {
+		if time.Since(lastUsed) < idleAfter {
+			return nil
+		}
+		_, err := conn.Do("PING")
+		return err
+	}
+}
@@ -0,0 +1,126 @@
+package webui
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIPoolStats(t *testing.T) {
+	pool := &redis.Pool{
+		MaxActive:   7,
+		MaxIdle:     5,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", ":6379")
+		},
+		Wait: true,
+	}
+	ns := "testwork"
+
+	// One connection sitting idle, one in use
+	conn := pool.Get()
+	_, err := conn.Do("PING")
+	assert.NoError(t, err)
+	conn.Close()
+	busy := pool.Get()
+	defer busy.Close()
+	_, err = busy.Do("PING")
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/pool_stats", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		Pool     map[string]interface{} `json:"pool"`
+		ReadPool map[string]interface{} `json:"read_pool"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"active_count":         float64(1),
+		"idle_count":           float64(0),
+		"max_active":           float64(7),
+		"max_idle":             float64(5),
+		"idle_timeout_seconds": float64(240),
+		"wait":                 true,
+	}, res.Pool)
+	assert.Nil(t, res.ReadPool)
+
+	s = NewServer(ns, pool, ":6666", "", "", WithReadPool(newTestPool(":6379")))
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/pool_stats", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	res.ReadPool = nil
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	if assert.NotNil(t, res.ReadPool) {
+		assert.EqualValues(t, 3, res.ReadPool["max_active"])
+	}
+}
+
+func TestWebUIConnectionCheck(t *testing.T) {
+	ns := "testwork"
+
+	// A pool whose connections we can drop out from under it, like redis does to idle clients
+	var netConns []net.Conn
+	newPool := func() *redis.Pool {
+		netConns = nil
+		return &redis.Pool{
+			MaxActive: 3,
+			MaxIdle:   3,
+			Dial: func() (redis.Conn, error) {
+				netConn, err := net.Dial("tcp", ":6379")
+				if err != nil {
+					return nil, err
+				}
+				netConns = append(netConns, netConn)
+				return redis.NewConn(netConn, time.Second, time.Second), nil
+			},
+			Wait: true,
+		}
+	}
+	dropConns := func() {
+		for _, netConn := range netConns {
+			netConn.Close()
+		}
+	}
+	getQueues := func(s *Server) int {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/queues", nil)
+		s.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// Without the check, the next request gets a dead connection
+	s := NewServer(ns, newPool(), ":6666", "", "")
+	assert.Equal(t, 200, getQueues(s))
+	dropConns()
+	assert.Equal(t, 500, getQueues(s))
+
+	// With it, the dead connection is replaced
+	s = NewServer(ns, newPool(), ":6666", "", "", WithConnectionCheck(0))
+	assert.Equal(t, 200, getQueues(s))
+	dropConns()
+	assert.Equal(t, 200, getQueues(s))
+	assert.Equal(t, 2, len(netConns))
+
+	// A pool's own TestOnBorrow is left alone
+	pool := newPool()
+	pool.TestOnBorrow = func(conn redis.Conn, lastUsed time.Time) error { return nil }
+	s = NewServer(ns, pool, ":6666", "", "", WithConnectionCheck(0))
+	assert.Equal(t, 200, getQueues(s))
+	dropConns()
+	assert.Equal(t, 500, getQueues(s))
+}
@@ -0,0 +1,178 @@
+package webui
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+const protobufContentType = "application/x-protobuf"
+
+// pbJob and pbJobList are the Job and JobList messages of jobs.proto. They're encoded by hand rather than with
+// generated code, since the messages are small and flat.
+type pbJob struct {
+	Name             string
+	ID               string
+	EnqueuedAt       int64
+	Args             []byte
+	Unique           bool
+	Fails            int64
+	LastErr          string
+	FailedAt         int64
+	RetryAt          int64
+	RunAt            int64
+	DiedAt           int64
+	Acked            bool
+	AnnotationStatus string
+	AnnotationNote   string
+}
+
+type pbJobList struct {
+	Count int64
+	Jobs  []*pbJob
+}
+
+// Protobuf wire types.
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+// wantsProtobuf reports whether the client asked for a protobuf response in its Accept header.
+func wantsProtobuf(r *web.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), protobufContentType)
+}
+
+// listedJob is a job in the response of one of the job list endpoints.
+type listedJob interface {
+	protobuf() *pbJob
+}
+
+// jobList is the response of the job list endpoints.
+type jobList struct {
+	Count int64       `json:"count"`
+	Jobs  []listedJob `json:"jobs"`
+}
+
+// renderJobs renders a job list as a protobuf JobList if the client asked for one in its Accept header, and as JSON
+// otherwise.
+func renderJobs(rw web.ResponseWriter, r *web.Request, jobs *jobList, err error) {
+	if err != nil || !wantsProtobuf(r) {
+		render(rw, jobs, err)
+		return
+	}
+
+	list := &pbJobList{Count: jobs.Count}
+	for _, j := range jobs.Jobs {
+		list.Jobs = append(list.Jobs, j.protobuf())
+	}
+	rw.Header().Set("Content-Type", protobufContentType)
+	rw.Write(list.marshal())
+}
+
+func newPBJob(job *work.Job, args json.RawMessage) *pbJob {
+	return &pbJob{
+		Name:       job.Name,
+		ID:         job.ID,
+		EnqueuedAt: job.EnqueuedAt,
+		Args:       args,
+		Unique:     job.Unique,
+		Fails:      job.Fails,
+		LastErr:    job.LastErr,
+		FailedAt:   job.FailedAt,
+	}
+}
+
+func (j *retryJob) protobuf() *pbJob {
+	pj := newPBJob(j.Job, j.Args)
+	pj.RetryAt = j.RetryAt
+	return pj
+}
+
+func (j *scheduledJob) protobuf() *pbJob {
+	pj := newPBJob(j.Job, j.Args)
+	pj.RunAt = j.RunAt
+	return pj
+}
+
+func (j *deadJob) protobuf() *pbJob {
+	pj := newPBJob(j.Job, j.Args)
+	pj.DiedAt = j.DiedAt
+	pj.Acked = j.Acked
+	if j.Annotation != nil {
+		pj.AnnotationStatus = j.Annotation.Status
+		pj.AnnotationNote = j.Annotation.Note
+	}
+	return pj
+}
+
+func (l *pbJobList) marshal() []byte {
+	var b []byte
+	b = appendPBInt64(b, 1, l.Count)
+	for _, j := range l.Jobs {
+		b = appendPBMessage(b, 2, j.marshal())
+	}
+	return b
+}
+
+func (j *pbJob) marshal() []byte {
+	var b []byte
+	b = appendPBBytes(b, 1, []byte(j.Name))
+	b = appendPBBytes(b, 2, []byte(j.ID))
+	b = appendPBInt64(b, 3, j.EnqueuedAt)
+	b = appendPBBytes(b, 4, j.Args)
+	b = appendPBBool(b, 5, j.Unique)
+	b = appendPBInt64(b, 6, j.Fails)
+	b = appendPBBytes(b, 7, []byte(j.LastErr))
+	b = appendPBInt64(b, 8, j.FailedAt)
+	b = appendPBInt64(b, 9, j.RetryAt)
+	b = appendPBInt64(b, 10, j.RunAt)
+	b = appendPBInt64(b, 11, j.DiedAt)
+	b = appendPBBool(b, 12, j.Acked)
+	b = appendPBBytes(b, 13, []byte(j.AnnotationStatus))
+	b = appendPBBytes(b, 14, []byte(j.AnnotationNote))
+	return b
+}
+
+// Fields with zero values are left out, as in proto3.
+
+func appendPBInt64(b []byte, field int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendPBVarint(b, uint64(field)<<3|pbVarint)
+	return appendPBVarint(b, uint64(v))
+}
+
+func appendPBBool(b []byte, field int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendPBInt64(b, field, 1)
+}
+
+func appendPBBytes(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	return appendPBMessage(b, field, v)
+}
+
+// appendPBMessage appends an element of a repeated message field, which is kept even if it's empty.
+func appendPBMessage(b []byte, field int, v []byte) []byte {
+	b = appendPBVarint(b, uint64(field)<<3|pbBytes)
+	b = appendPBVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendPBVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
@@ -0,0 +1,281 @@
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+// The server only encodes protobuf; the decoder is for checking what it encodes.
+
+var errMalformedProtobuf = errors.New("malformed protobuf")
+
+// unmarshalPBJobList decodes a JobList, skipping any fields it doesn't know.
+func unmarshalPBJobList(b []byte) (*pbJobList, error) {
+	l := &pbJobList{}
+	err := readPBFields(b, func(field, wireType int, v uint64, data []byte) error {
+		switch {
+		case field == 1 && wireType == pbVarint:
+			l.Count = int64(v)
+		case field == 2 && wireType == pbBytes:
+			j, err := unmarshalPBJob(data)
+			if err != nil {
+				return err
+			}
+			l.Jobs = append(l.Jobs, j)
+		}
+		return nil
+	})
+	return l, err
+}
+
+func unmarshalPBJob(b []byte) (*pbJob, error) {
+	j := &pbJob{}
+	err := readPBFields(b, func(field, wireType int, v uint64, data []byte) error {
+		if wireType == pbBytes {
+			switch field {
+			case 1:
+				j.Name = string(data)
+			case 2:
+				j.ID = string(data)
+			case 4:
+				j.Args = data
+			case 7:
+				j.LastErr = string(data)
+			case 13:
+				j.AnnotationStatus = string(data)
+			case 14:
+				j.AnnotationNote = string(data)
+			}
+		} else if wireType == pbVarint {
+			switch field {
+			case 3:
+				j.EnqueuedAt = int64(v)
+			case 5:
+				j.Unique = v != 0
+			case 6:
+				j.Fails = int64(v)
+			case 8:
+				j.FailedAt = int64(v)
+			case 9:
+				j.RetryAt = int64(v)
+			case 10:
+				j.RunAt = int64(v)
+			case 11:
+				j.DiedAt = int64(v)
+			case 12:
+				j.Acked = v != 0
+			}
+		}
+		return nil
+	})
+	return j, err
+}
+
+func readPBVarint(b []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i] < 0x80 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errMalformedProtobuf
+}
+
+// readPBFields calls fn with each field in b. For varint fields, v is the value; for length-delimited fields, data is.
+func readPBFields(b []byte, fn func(field, wireType int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		key, n, err := readPBVarint(b)
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+		field, wireType := int(key>>3), int(key&7)
+
+		var v uint64
+		var data []byte
+		switch wireType {
+		case pbVarint:
+			v, n, err = readPBVarint(b)
+			if err != nil {
+				return err
+			}
+		case pbFixed64:
+			n = 8
+		case pbFixed32:
+			n = 4
+		case pbBytes:
+			var size uint64
+			size, n, err = readPBVarint(b)
+			if err != nil {
+				return err
+			}
+			if size > uint64(len(b)-n) {
+				return errMalformedProtobuf
+			}
+			data = b[n : n+int(size)]
+			n += int(size)
+		default:
+			return errMalformedProtobuf
+		}
+		if n > len(b) {
+			return errMalformedProtobuf
+		}
+		b = b[n:]
+
+		if err := fn(field, wireType, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestProtobufJobListRoundTrip(t *testing.T) {
+	list := &pbJobList{
+		Count: 300,
+		Jobs: []*pbJob{
+			{
+				Name:             "wat",
+				ID:               "a",
+				EnqueuedAt:       1425263409,
+				Args:             []byte(`{"a":1}`),
+				Unique:           true,
+				Fails:            3,
+				LastErr:          "sorry",
+				FailedAt:         1425263419,
+				DiedAt:           1425263429,
+				Acked:            true,
+				AnnotationStatus: "investigating",
+				AnnotationNote:   "ü",
+			},
+			{Name: "foo", ID: "b", RetryAt: -1},
+			{},
+		},
+	}
+
+	decoded, err := unmarshalPBJobList(list.marshal())
+	assert.NoError(t, err)
+	assert.Equal(t, list, decoded)
+
+	// Unknown fields are skipped
+	b := appendPBInt64(nil, 15, 7)
+	b = appendPBBytes(b, 16, []byte("later"))
+	b = append(b, list.marshal()...)
+	decoded, err = unmarshalPBJobList(b)
+	assert.NoError(t, err)
+	assert.Equal(t, list, decoded)
+
+	// Truncated
+	b = list.marshal()
+	_, err = unmarshalPBJobList(b[:len(b)-1])
+	assert.Equal(t, errMalformedProtobuf, err)
+}
+
+func TestProtobufJobListWireFormat(t *testing.T) {
+	list := &pbJobList{
+		Count: 300,
+		Jobs: []*pbJob{
+			{Name: "wat", ID: "a", EnqueuedAt: 150, Unique: true, RetryAt: -1},
+			{},
+		},
+	}
+
+	// The encoding the protobuf wire format spec gives for the message, worked out independently of the encoder
+	want := []byte{
+		0x08, 0xac, 0x02, // count = 300
+		0x12, 0x18, // jobs, 24 bytes
+		0x0a, 0x03, 'w', 'a', 't', // name = "wat"
+		0x12, 0x01, 'a', // id = "a"
+		0x18, 0x96, 0x01, // enqueued_at = 150
+		0x28, 0x01, // unique = true
+		0x48, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, // retry_at = -1
+		0x12, 0x00, // jobs, an empty job
+	}
+	assert.Equal(t, want, list.marshal())
+}
+
+func TestWebUIJobListsProtobuf(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertRetryJobWithFails(ns, pool, "wat", 100, 10, 2)
+	insertRetryJobWithFails(ns, pool, "foo", 101, 11, 5)
+	insertDeadJob(ns, pool, "wat", 1, 12)
+	client := work.NewClient(ns, pool)
+	assert.NoError(t, client.AnnotateDeadJob(12, "wat-12", &work.DeadJobAnnotation{Status: "investigating"}))
+	enqueuer := work.NewEnqueuer(ns, pool)
+	_, err := enqueuer.EnqueueIn("bar", 60, work.Q{"a": 1})
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	get := func(path string, protobuf bool) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		if protobuf {
+			request.Header.Set("Accept", protobufContentType)
+		}
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		return recorder
+	}
+
+	for _, path := range []string{"/retry_jobs", "/retry_jobs/exhausted?max_fails=5", "/scheduled_jobs", "/dead_jobs"} {
+		recorder := get(path, true)
+		assert.Equal(t, protobufContentType, recorder.Header().Get("Content-Type"), path)
+		list, err := unmarshalPBJobList(recorder.Body.Bytes())
+		assert.NoError(t, err, path)
+
+		// It has the same jobs as the JSON response, which is still the default
+		recorder = get(path, false)
+		assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"), path)
+		var res struct {
+			Count int64 `json:"count"`
+			Jobs  []struct {
+				ID         string          `json:"id"`
+				Name       string          `json:"name"`
+				Fails      int64           `json:"fails"`
+				Args       json.RawMessage `json:"args"`
+				RetryAt    int64           `json:"retry_at"`
+				RunAt      int64           `json:"run_at"`
+				DiedAt     int64           `json:"died_at"`
+				Annotation *struct {
+					Status string `json:"status"`
+				} `json:"annotation"`
+			} `json:"jobs"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res), path)
+
+		assert.Equal(t, res.Count, list.Count, path)
+		if assert.Equal(t, len(res.Jobs), len(list.Jobs), path) {
+			for i, j := range res.Jobs {
+				pj := list.Jobs[i]
+				assert.Equal(t, j.ID, pj.ID, path)
+				assert.Equal(t, j.Name, pj.Name, path)
+				assert.Equal(t, j.Fails, pj.Fails, path)
+				assert.Equal(t, j.RetryAt, pj.RetryAt, path)
+				assert.Equal(t, j.RunAt, pj.RunAt, path)
+				assert.Equal(t, j.DiedAt, pj.DiedAt, path)
+				assert.JSONEq(t, string(j.Args), string(pj.Args), path)
+				if j.Annotation != nil {
+					assert.Equal(t, j.Annotation.Status, pj.AnnotationStatus, path)
+				}
+			}
+		}
+	}
+
+	recorder := get("/dead_jobs", true)
+	list, err := unmarshalPBJobList(recorder.Body.Bytes())
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(list.Jobs)) {
+		assert.Equal(t, "investigating", list.Jobs[0].AnnotationStatus)
+		assert.EqualValues(t, 12, list.Jobs[0].DiedAt)
+	}
+}
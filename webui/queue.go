@@ -0,0 +1,130 @@
+package webui
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gocraft/web"
+)
+
+// queueDetail is everything the UI shows about a single queue.
+type queueDetail struct {
+	JobName     string `json:"job_name"`
+	Count       int64  `json:"count"`
+	Latency     int64  `json:"latency"`
+	Concurrency uint   `json:"concurrency"`
+	InProgress  int64  `json:"in_progress"`
+	RetryJobs   int64  `json:"retry_jobs"`
+	DeadJobs    int64  `json:"dead_jobs"`
+}
+
+// queue returns the depth, latency, configured concurrency, in-progress jobs, and retry and dead job counts of a
+// single queue in one call. It 404s if the job name isn't known.
+func (c *context) queue(rw web.ResponseWriter, r *web.Request) {
+	jobName := r.PathParams["queue"]
+
+	queues, err := c.readClient.Queues()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	var detail *queueDetail
+	for _, q := range queues {
+		if q.JobName == jobName {
+			detail = &queueDetail{JobName: jobName, Count: q.Count, Latency: q.Latency}
+			break
+		}
+	}
+	if detail == nil {
+		renderErrorCode(rw, http.StatusNotFound, errors.New("queue not found"))
+		return
+	}
+
+	knownJobs, err := c.readClient.KnownJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	for _, j := range knownJobs {
+		if j.JobName == jobName {
+			detail.Concurrency = j.MaxConcurrency
+			detail.InProgress = j.InProgress
+		}
+	}
+
+	retryJobs, err := c.readClient.AllRetryJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	for _, j := range retryJobs {
+		if j.Name == jobName {
+			detail.RetryJobs++
+		}
+	}
+
+	deadJobs, err := c.readClient.AllDeadJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	for _, j := range deadJobs {
+		if j.Name == jobName {
+			detail.DeadJobs++
+		}
+	}
+
+	render(rw, detail, nil)
+}
+
+// moveToDeadLetter moves all of the pending jobs in the queue param to the dead-letter queue set with
+// WithDeadLetterQueue, and returns how many were moved. It's only served with that option.
+func (c *context) moveToDeadLetter(rw web.ResponseWriter, r *web.Request) {
+	jobName := r.PathParams["queue"]
+	if jobName == c.config.deadLetterQueue {
+		renderError(rw, badRequestError("can't move the dead-letter queue into itself"))
+		return
+	}
+
+	moved, err := c.client.MoveQueueJobs(jobName, c.config.deadLetterQueue)
+
+	response := struct {
+		Moved           int64  `json:"moved"`
+		DeadLetterQueue string `json:"dead_letter_queue"`
+	}{Moved: moved, DeadLetterQueue: c.config.deadLetterQueue}
+
+	render(rw, response, err)
+}
+
+// moveJobQueue moves the pending jobs named by the name param from one queue to another, given as from and to in the
+// JSON body, and returns how many were moved. Jobs with other names in the from queue stay where they are. The move is
+// atomic.
+func (c *context) moveJobQueue(rw web.ResponseWriter, r *web.Request) {
+	var req struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := decodeJSONBody(r, &req, c.config); err != nil {
+		renderError(rw, err)
+		return
+	}
+	if req.From == "" || req.To == "" {
+		renderError(rw, badRequestError("from and to are required"))
+		return
+	}
+	if req.From == req.To {
+		renderError(rw, badRequestError("from and to must be different queues"))
+		return
+	}
+
+	moved, err := c.client.MoveNamedQueueJobs(r.PathParams["name"], req.From, req.To)
+
+	response := struct {
+		Moved int64  `json:"moved"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+	}{Moved: moved, From: req.From, To: req.To}
+
+	render(rw, response, err)
+}
@@ -0,0 +1,112 @@
+package webui
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gocraft/web"
+)
+
+// queueRates tracks each queue's enqueue and dequeue rates between the last two samples. Nothing counts enqueues
+// directly, so the enqueue rate is derived from how much the queue grew plus how many jobs workers took off it over the
+// sampler interval. The dequeue rate is how many jobs workers processed.
+type queueRates struct {
+	mtx          sync.Mutex
+	sampledAt    int64
+	depths       map[string]int64
+	processed    map[string]int64
+	rates        map[string]float64
+	dequeueRates map[string]float64
+}
+
+type queueRate struct {
+	JobName     string  `json:"job_name"`
+	EnqueueRate float64 `json:"enqueue_rate"`
+}
+
+// sampleQueueRates is run by the sampler to record the queue depths and processed counts, and update the rates. A
+// sample that fails to read redis is skipped; the next one covers the longer interval.
+func (w *Server) sampleQueueRates() {
+	queues, err := w.readClient.Queues()
+	if err != nil {
+		return
+	}
+	processed, err := w.readClient.ProcessedCounts()
+	if err != nil {
+		return
+	}
+
+	depths := make(map[string]int64, len(queues))
+	for _, q := range queues {
+		depths[q.JobName] = q.Count
+	}
+
+	now := nowEpochSeconds()
+
+	qr := &w.queueRates
+	qr.mtx.Lock()
+	defer qr.mtx.Unlock()
+
+	if elapsed := now - qr.sampledAt; qr.depths != nil && elapsed > 0 {
+		qr.rates = make(map[string]float64, len(depths))
+		qr.dequeueRates = make(map[string]float64, len(depths))
+		for jobName, depth := range depths {
+			dequeued := processed[jobName] - qr.processed[jobName]
+			if dequeued < 0 {
+				dequeued = 0
+			}
+			enqueued := depth - qr.depths[jobName] + dequeued
+			if enqueued < 0 {
+				enqueued = 0
+			}
+			qr.rates[jobName] = float64(enqueued) / float64(elapsed)
+			qr.dequeueRates[jobName] = float64(dequeued) / float64(elapsed)
+		}
+	}
+
+	qr.sampledAt = now
+	qr.depths = depths
+	qr.processed = processed
+}
+
+// reset forgets the samples, so that rates start over from the next two samples. It returns the number of queues that
+// had samples.
+func (qr *queueRates) reset() int {
+	qr.mtx.Lock()
+	defer qr.mtx.Unlock()
+
+	n := len(qr.depths)
+	qr.sampledAt = 0
+	qr.depths = nil
+	qr.processed = nil
+	qr.rates = nil
+	qr.dequeueRates = nil
+	return n
+}
+
+// queueRates returns the approximate number of jobs enqueued per second on each queue. It's a sampled approximation:
+// the rate is averaged over the last sampler interval (see WithSamplerInterval and /sampling_config), and it's only available once the
+// sampler has run twice.
+func (c *context) queueRates(rw web.ResponseWriter, r *web.Request) {
+	qr := &c.Server.queueRates
+	qr.mtx.Lock()
+	response := struct {
+		IntervalSecs float64      `json:"interval_secs"`
+		SampledAt    int64        `json:"sampled_at"`
+		Rates        []*queueRate `json:"rates"`
+	}{
+		IntervalSecs: c.sampler.currentInterval().Seconds(),
+		SampledAt:    qr.sampledAt,
+		Rates:        make([]*queueRate, 0, len(qr.rates)),
+	}
+	for jobName, rate := range qr.rates {
+		response.Rates = append(response.Rates, &queueRate{JobName: jobName, EnqueueRate: rate})
+	}
+	qr.mtx.Unlock()
+
+	sort.Slice(response.Rates, func(i, j int) bool {
+		return response.Rates[i].JobName < response.Rates[j].JobName
+	})
+
+	render(rw, response, nil)
+}
@@ -0,0 +1,74 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIQueueRates(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	_, err := enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	getRates := func() []queueRate {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/queues/rates", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res struct {
+			IntervalSecs float64     `json:"interval_secs"`
+			Rates        []queueRate `json:"rates"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 10, res.IntervalSecs)
+		return res.Rates
+	}
+
+	// A single sample isn't enough to know a rate
+	s.sampleQueueRates()
+	assert.Equal(t, 0, len(getRates()))
+
+	// 20 enqueued over 10 seconds, of which 5 were processed
+	for i := 0; i < 20; i++ {
+		_, err := enqueuer.Enqueue("wat", nil)
+		assert.NoError(t, err)
+	}
+	conn := pool.Get()
+	for i := 0; i < 5; i++ {
+		_, err := conn.Do("RPOP", ns+":jobs:wat")
+		assert.NoError(t, err)
+	}
+	_, err = conn.Do("HINCRBY", ns+":processed", "wat", 5)
+	assert.NoError(t, err)
+	conn.Close()
+
+	setNowEpochSecondsMock(1425263419)
+	s.sampleQueueRates()
+	assert.Equal(t, []queueRate{{JobName: "wat", EnqueueRate: 2}}, getRates())
+
+	// Draining the queue isn't a negative enqueue rate
+	conn = pool.Get()
+	_, err = conn.Do("DEL", ns+":jobs:wat")
+	assert.NoError(t, err)
+	conn.Close()
+
+	setNowEpochSecondsMock(1425263429)
+	s.sampleQueueRates()
+	assert.Equal(t, []queueRate{{JobName: "wat", EnqueueRate: 0}}, getRates())
+}
@@ -0,0 +1,182 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIQueue(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertQueuedJob(ns, pool, "wat", 1)
+	insertQueuedJob(ns, pool, "wat", 2)
+	insertQueuedJob(ns, pool, "foo", 3)
+	insertRetryJob(ns, pool, "wat", 100, 10)
+	insertRetryJob(ns, pool, "foo", 101, 11)
+	insertDeadJob(ns, pool, "wat", 1, 12)
+	insertDeadJob(ns, pool, "wat", 1, 13)
+	insertDeadJob(ns, pool, "wat", 1, 14)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":worker_pools", "pool1", "pool2")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:pool1", "job_names", "wat,foo", "concurrency", 10)
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:pool2", "job_names", "wat", "concurrency", 5)
+	assert.NoError(t, err)
+	_, err = conn.Do("LPUSH", ns+":jobs:wat:pool2:inprogress", `{"name":"wat","id":"a"}`)
+	assert.NoError(t, err)
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/queue/wat", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res queueDetail
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "wat", res.JobName)
+	assert.EqualValues(t, 2, res.Count)
+	assert.True(t, res.Latency > 0)
+	assert.EqualValues(t, 15, res.Concurrency)
+	assert.EqualValues(t, 1, res.InProgress)
+	assert.EqualValues(t, 1, res.RetryJobs)
+	assert.EqualValues(t, 3, res.DeadJobs)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/queue/nope", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+}
+
+func TestWebUIMoveToDeadLetter(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	for i := 0; i < 3; i++ {
+		_, err := enqueuer.Enqueue("wat", work.Q{"i": i})
+		assert.NoError(t, err)
+	}
+
+	post := func(s *Server, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// Without a dead-letter queue there's nowhere to move jobs
+	s := NewServer(ns, pool, ":6666", "", "")
+	assert.Equal(t, 404, post(s, "/queue/wat/to_deadletter").Code)
+
+	s = NewServer(ns, pool, ":6666", "", "", WithDeadLetterQueue("deadletter"))
+	recorder := post(s, "/queue/wat/to_deadletter")
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"moved": 3, "dead_letter_queue": "deadletter", "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+
+	queues, err := s.client.Queues()
+	assert.NoError(t, err)
+	counts := map[string]int64{}
+	for _, q := range queues {
+		counts[q.JobName] = q.Count
+	}
+	assert.Equal(t, map[string]int64{"wat": 0, "deadletter": 3}, counts)
+
+	jobs, err := s.client.PeekJobs("deadletter", 3)
+	assert.NoError(t, err)
+	for i, j := range jobs {
+		assert.Equal(t, "wat", j.Name)
+		assert.EqualValues(t, i, j.ArgInt64("i"))
+	}
+
+	assert.Equal(t, 400, post(s, "/queue/deadletter/to_deadletter").Code)
+}
+
+func TestWebUIMoveToDeadLetterEmptyQueue(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":known_jobs", "wat")
+	conn.Close()
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithDeadLetterQueue("deadletter"))
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/queue/wat/to_deadletter", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"moved": 0, "dead_letter_queue": "deadletter", "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+
+	// Moving nothing doesn't add an empty dead-letter queue to the queues
+	queues, err := s.client.Queues()
+	assert.NoError(t, err)
+	var names []string
+	for _, q := range queues {
+		names = append(names, q.JobName)
+	}
+	assert.Equal(t, []string{"wat"}, names)
+}
+
+func TestWebUIMoveJobQueue(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	// A dead-letter queue holding a mix of jobs
+	enqueuer := work.NewEnqueuer(ns, pool)
+	client := work.NewClient(ns, pool)
+	for _, name := range []string{"wat", "foo", "wat", "foo"} {
+		_, err := enqueuer.Enqueue(name, nil)
+		assert.NoError(t, err)
+		_, err = client.MoveQueueJobs(name, "deadletter")
+		assert.NoError(t, err)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+	post := func(path, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := post("/job/wat/move_queue", `{"from": "deadletter", "to": "wat"}`)
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"moved": 2, "from": "deadletter", "to": "wat", "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+
+	names := func(jobName string) []string {
+		jobs, err := client.PeekJobs(jobName, 10)
+		assert.NoError(t, err)
+		var names []string
+		for _, j := range jobs {
+			names = append(names, j.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"wat", "wat"}, names("wat"))
+	assert.Equal(t, []string{"foo", "foo"}, names("deadletter"))
+
+	assert.Equal(t, 400, post("/job/wat/move_queue", `{"from": "wat"}`).Code)
+	assert.Equal(t, 400, post("/job/wat/move_queue", `{"from": "wat", "to": "wat"}`).Code)
+
+	recorder = post("/job/wat/move_queue", `{"a":`)
+	assert.Equal(t, 400, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid request body")
+	assert.Equal(t, 400, post("/job/wat/move_queue", `{"from": 1, "to": "wat"}`).Code)
+}
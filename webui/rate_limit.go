@@ -0,0 +1,39 @@
+package webui
+
+import (
+	"strconv"
+
+	"github.com/gocraft/web"
+)
+
+type rateLimit struct {
+	RateLimit int64 `json:"rate_limit"`
+}
+
+// rateLimit returns the namespace-wide job processing rate limit, in jobs per second, which workers apply when fetching
+// jobs. Zero means there's no limit.
+func (c *context) rateLimit(rw web.ResponseWriter, r *web.Request) {
+	limit, err := c.readClient.RateLimit()
+	render(rw, &rateLimit{RateLimit: limit}, err)
+}
+
+// setRateLimit sets the rate limit from the rate_limit form field. Zero removes the limit.
+func (c *context) setRateLimit(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	limit, err := strconv.ParseInt(r.Form.Get("rate_limit"), 10, 64)
+	if err != nil {
+		renderError(rw, badParam("rate_limit", err))
+		return
+	}
+
+	if err := c.client.SetRateLimit(limit); err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	render(rw, &rateLimit{RateLimit: limit}, nil)
+}
@@ -0,0 +1,55 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIRateLimit(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	getRateLimit := func() int64 {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/rate_limit", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		var res rateLimit
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		return res.RateLimit
+	}
+	setRateLimit := func(value string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		form := url.Values{"rate_limit": {value}}
+		request, _ := http.NewRequest("POST", "/rate_limit", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.EqualValues(t, 0, getRateLimit())
+
+	recorder := setRateLimit("50")
+	assert.Equal(t, 200, recorder.Code)
+	assert.EqualValues(t, 50, getRateLimit())
+
+	recorder = setRateLimit("lots")
+	assert.Equal(t, 400, recorder.Code)
+	recorder = setRateLimit("-5")
+	assert.Equal(t, 500, recorder.Code)
+	assert.EqualValues(t, 50, getRateLimit())
+
+	recorder = setRateLimit("0")
+	assert.Equal(t, 200, recorder.Code)
+	assert.EqualValues(t, 0, getRateLimit())
+}
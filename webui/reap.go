@@ -0,0 +1,30 @@
+package webui
+
+import (
+	"github.com/gocraft/web"
+)
+
+// reap removes auxiliary data left behind by crashed worker pools, returning how many of each kind it removed:
+// worker observations of pools the dead pool reaper would consider dead, and IDs of pools whose heartbeat has expired
+// and that have no in-progress jobs left. Jobs, and anything a live pool might still use, are never touched. It's only
+// served with WithReap.
+func (c *context) reap(rw web.ResponseWriter, r *web.Request) {
+	observations, err := c.client.ReapWorkerObservations()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	pools, err := c.client.ReapWorkerPools()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := struct {
+		WorkerObservations int64 `json:"worker_observations"`
+		WorkerPools        int64 `json:"worker_pools"`
+	}{WorkerObservations: observations, WorkerPools: pools}
+
+	render(rw, response, nil)
+}
@@ -0,0 +1,161 @@
+package webui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+const (
+	defaultRecoveryWindow = 60 * 60
+	// maxRecoveryWindow is how long recovered jobs are remembered, in seconds.
+	maxRecoveryWindow = 24 * 60 * 60
+)
+
+// recoveredJob is a job that left the retry or dead set and then left its queue without failing again.
+type recoveredJob struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Fails       int64  `json:"fails"`
+	RecoveredAt int64  `json:"recovered_at"`
+}
+
+// recoveries reconstructs which failed jobs went on to be processed from samples of the retry and dead sets. Nothing
+// records a retried job's success, so a job counts as recovered once it's out of both sets and no longer in its queue.
+// That makes it an approximation: a job deleted from the dead set looks the same, and a job still being processed is
+// counted until it fails again.
+type recoveries struct {
+	mtx sync.Mutex
+	// failing is the jobs in the retry and dead sets at the last sample, by ID.
+	failing map[string]*work.Job
+	// requeued is the jobs that have left the failing sets but are still queued, by ID.
+	requeued  map[string]*work.Job
+	recovered []*recoveredJob
+}
+
+// sampleRecoveries is run by the sampler to look for jobs moving out of the retry and dead sets.
+func (w *Server) sampleRecoveries() {
+	retryJobs, err := w.readClient.AllRetryJobs()
+	if err != nil {
+		return
+	}
+	deadJobs, err := w.readClient.AllDeadJobs()
+	if err != nil {
+		return
+	}
+
+	failing := make(map[string]*work.Job, len(retryJobs)+len(deadJobs))
+	for _, j := range retryJobs {
+		failing[j.ID] = j.Job
+	}
+	for _, j := range deadJobs {
+		failing[j.ID] = j.Job
+	}
+
+	w.recoveries.observe(nowEpochSeconds(), failing, func(job *work.Job) (bool, error) {
+		queued, err := w.readClient.FindQueuedJob(job.Name, job.ID)
+		return queued != nil, err
+	})
+}
+
+// observe records a sample of the failing jobs taken at now. isQueued reports whether a job is waiting in its queue.
+func (rc *recoveries) observe(now int64, failing map[string]*work.Job, isQueued func(*work.Job) (bool, error)) {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+
+	if rc.requeued == nil {
+		rc.requeued = map[string]*work.Job{}
+	}
+
+	for id, job := range rc.failing {
+		if failing[id] == nil {
+			rc.requeued[id] = job
+		}
+	}
+
+	for id, job := range rc.requeued {
+		if failing[id] != nil {
+			// It failed again
+			delete(rc.requeued, id)
+			continue
+		}
+		queued, err := isQueued(job)
+		if err != nil || queued {
+			continue
+		}
+		rc.recovered = append(rc.recovered, &recoveredJob{ID: id, Name: job.Name, Fails: job.Fails, RecoveredAt: now})
+		delete(rc.requeued, id)
+	}
+
+	// Forget jobs that fell outside the longest window, or that have failed again since they seemed to recover.
+	recovered := rc.recovered[:0]
+	for _, j := range rc.recovered {
+		if j.RecoveredAt >= now-maxRecoveryWindow && failing[j.ID] == nil {
+			recovered = append(recovered, j)
+		}
+	}
+	rc.recovered = recovered
+
+	rc.failing = failing
+}
+
+// since returns the jobs that recovered at or after t, most recent first.
+func (rc *recoveries) since(t int64) []*recoveredJob {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+
+	jobs := []*recoveredJob{}
+	for _, j := range rc.recovered {
+		if j.RecoveredAt >= t {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].RecoveredAt > jobs[j].RecoveredAt
+	})
+	return jobs
+}
+
+// reset forgets the failing jobs and recoveries seen so far. It returns the number of jobs it was tracking.
+func (rc *recoveries) reset() int {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+
+	n := len(rc.failing) + len(rc.requeued) + len(rc.recovered)
+	rc.failing = nil
+	rc.requeued = nil
+	rc.recovered = nil
+	return n
+}
+
+// recoveredJobs lists the jobs that recovered from the retry or dead set within the last window_secs seconds (an hour
+// by default, at most a day), as reconstructed by the sampler. It only covers the time since the server started.
+func (c *context) recoveredJobs(rw web.ResponseWriter, r *web.Request) {
+	window := int64(defaultRecoveryWindow)
+	if windowStr := r.URL.Query().Get("window_secs"); windowStr != "" {
+		var err error
+		window, err = strconv.ParseInt(windowStr, 10, 64)
+		if err != nil {
+			renderError(rw, badParam("window_secs", err))
+			return
+		}
+		if window < 1 || window > maxRecoveryWindow {
+			renderError(rw, badRequestError(fmt.Sprintf("window_secs must be between 1 and %d", maxRecoveryWindow)))
+			return
+		}
+	}
+
+	jobs := c.recoveries.since(nowEpochSeconds() - window)
+
+	response := struct {
+		WindowSecs int64           `json:"window_secs"`
+		Count      int             `json:"count"`
+		Jobs       []*recoveredJob `json:"jobs"`
+	}{WindowSecs: window, Count: len(jobs), Jobs: jobs}
+
+	render(rw, response, nil)
+}
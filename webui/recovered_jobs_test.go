@@ -0,0 +1,112 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveriesObserve(t *testing.T) {
+	a := &work.Job{Name: "wat", ID: "a", Fails: 1}
+	b := &work.Job{Name: "wat", ID: "b", Fails: 2}
+	c := &work.Job{Name: "foo", ID: "c", Fails: 3}
+
+	queued := map[string]bool{}
+	isQueued := func(job *work.Job) (bool, error) {
+		return queued[job.ID], nil
+	}
+
+	var rc recoveries
+	rc.observe(100, map[string]*work.Job{"a": a, "b": b, "c": c}, isQueued)
+	assert.Equal(t, 0, len(rc.since(0)))
+
+	// a was retried and processed, b was retried but is still queued, c is still failing
+	queued["b"] = true
+	rc.observe(110, map[string]*work.Job{"c": c}, isQueued)
+	assert.Equal(t, []*recoveredJob{{ID: "a", Name: "wat", Fails: 1, RecoveredAt: 110}}, rc.since(0))
+
+	// b was processed, and c was retried and processed
+	queued["b"] = false
+	rc.observe(120, map[string]*work.Job{}, isQueued)
+	jobs := rc.since(0)
+	if assert.Equal(t, 3, len(jobs)) {
+		assert.EqualValues(t, 120, jobs[0].RecoveredAt)
+		assert.EqualValues(t, 120, jobs[1].RecoveredAt)
+		assert.Equal(t, "a", jobs[2].ID)
+	}
+	assert.Equal(t, 2, len(rc.since(115)))
+
+	// c failed again after all
+	rc.observe(130, map[string]*work.Job{"c": c}, isQueued)
+	jobs = rc.since(0)
+	if assert.Equal(t, 2, len(jobs)) {
+		assert.Equal(t, "b", jobs[0].ID)
+		assert.Equal(t, "a", jobs[1].ID)
+	}
+
+	// Recoveries are forgotten after the longest window
+	rc.observe(130+maxRecoveryWindow, map[string]*work.Job{"c": c}, isQueued)
+	assert.Equal(t, 0, len(rc.since(0)))
+}
+
+func TestWebUIRecoveredJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	job := insertDeadJob(ns, pool, "wat", 1, 10)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+	s.sampleRecoveries()
+
+	// Retried, and still waiting in the queue
+	client := work.NewClient(ns, pool)
+	assert.NoError(t, client.RetryDeadJob(10, job.ID))
+	setNowEpochSecondsMock(1425263419)
+	s.sampleRecoveries()
+
+	// And processed
+	conn := pool.Get()
+	_, err := conn.Do("DEL", ns+":jobs:wat")
+	assert.NoError(t, err)
+	conn.Close()
+	setNowEpochSecondsMock(1425263429)
+	s.sampleRecoveries()
+
+	var res struct {
+		WindowSecs int64          `json:"window_secs"`
+		Count      int            `json:"count"`
+		Jobs       []recoveredJob `json:"jobs"`
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/recovered_jobs?window_secs=60", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 60, res.WindowSecs)
+	assert.Equal(t, 1, res.Count)
+	assert.Equal(t, []recoveredJob{{ID: job.ID, Name: "wat", Fails: job.Fails, RecoveredAt: 1425263429}}, res.Jobs)
+
+	setNowEpochSecondsMock(1425263429 + 120)
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/recovered_jobs?window_secs=60", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, res.Count)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/recovered_jobs?window_secs=0", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+}
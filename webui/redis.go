@@ -0,0 +1,36 @@
+package webui
+
+// Keys for state the web UI keeps for itself. They live under the work namespace, in a "webui:" sub-namespace so they
+// can't collide with keys written by work.
+
+func redisNamespacePrefix(namespace string) string {
+	l := len(namespace)
+	if (l > 0) && (namespace[l-1] != ':') {
+		namespace = namespace + ":"
+	}
+	return namespace
+}
+
+func redisKeyWebUIPrefix(namespace string) string {
+	return redisNamespacePrefix(namespace) + "webui:"
+}
+
+func redisKeySavedFilters(namespace string) string {
+	return redisKeyWebUIPrefix(namespace) + "saved_filters"
+}
+
+func redisKeyViewStates(namespace string) string {
+	return redisKeyWebUIPrefix(namespace) + "view_states"
+}
+
+func redisKeyAlertThresholds(namespace string) string {
+	return redisKeyWebUIPrefix(namespace) + "alert_thresholds"
+}
+
+func redisKeyBaseline(namespace string) string {
+	return redisKeyWebUIPrefix(namespace) + "baseline"
+}
+
+func redisKeyRetention(namespace string) string {
+	return redisKeyWebUIPrefix(namespace) + "retention"
+}
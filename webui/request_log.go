@@ -0,0 +1,166 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocraft/web"
+)
+
+// RequestLogFormat is the format of the lines written by WithRequestLog.
+type RequestLogFormat int
+
+const (
+	// RequestLogText writes a line of text per request, for humans.
+	RequestLogText RequestLogFormat = iota
+	// RequestLogJSON writes a JSON object per request, one per line, for log pipelines.
+	RequestLogJSON
+)
+
+// requestLogEntry is what's logged about each request. Requests that fail with a 5xx are logged at the error level,
+// the others at the info level.
+type requestLogEntry struct {
+	Level      string  `json:"level"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	User       string  `json:"user"`
+	RequestID  string  `json:"request_id"`
+	RemoteIP   string  `json:"remote_ip"`
+}
+
+// requestDebugEntry is the detail logged about each request at the debug level, just before its requestLogEntry.
+type requestDebugEntry struct {
+	Level     string `json:"level"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Query     string `json:"query"`
+	UserAgent string `json:"user_agent"`
+	RequestID string `json:"request_id"`
+}
+
+// requestPanicEntry is what's logged, at the error level, about a panic recovered while handling a request.
+type requestPanicEntry struct {
+	Level     string `json:"level"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Panic     string `json:"panic"`
+	Stack     string `json:"stack"`
+	RequestID string `json:"request_id"`
+}
+
+// requestLogger writes an entry per request to out, skipping those less severe than its level. Writes are serialized
+// so that entries from concurrent requests don't interleave.
+type requestLogger struct {
+	mtx    sync.Mutex
+	out    io.Writer
+	format RequestLogFormat
+	level  int32 // a logLevel, changed at runtime through /log_level
+}
+
+// logRequest logs the request once the later middleware and handler are done with it.
+func (c *context) logRequest(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+	start := time.Now()
+	next(rw, r)
+
+	entry := &requestLogEntry{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     rw.StatusCode(),
+		DurationMS: float64(time.Since(start)) / float64(time.Millisecond),
+		User:       c.user,
+		RequestID:  c.requestID,
+		RemoteIP:   r.RemoteAddr,
+	}
+	if entry.User == "" {
+		entry.User = requestUser(r)
+	}
+	if entry.Status == 0 {
+		// Nothing was written, which net/http sends as a 200
+		entry.Status = http.StatusOK
+	}
+	level := logLevelInfo
+	if entry.Status >= 500 {
+		level = logLevelError
+	}
+	entry.Level = level.String()
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		entry.RemoteIP = host
+	}
+
+	l := c.config.requestLog
+	if l.enabled(logLevelDebug) {
+		l.writeDebug(&requestDebugEntry{
+			Level:     logLevelDebug.String(),
+			Method:    entry.Method,
+			Path:      entry.Path,
+			Query:     r.URL.RawQuery,
+			UserAgent: r.UserAgent(),
+			RequestID: entry.RequestID,
+		})
+	}
+	if l.enabled(level) {
+		l.write(entry)
+	}
+}
+
+// requestUser returns who made the request: the basic auth username, or else the verified client certificate's
+// subject. It's empty for anonymous requests.
+func requestUser(r *web.Request) string {
+	if username, _, ok := r.BasicAuth(); ok {
+		return username
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return ""
+}
+
+func (l *requestLogger) write(entry *requestLogEntry) {
+	if l.format == RequestLogJSON {
+		l.writeJSON(entry)
+		return
+	}
+	l.writeLine(fmt.Sprintf("%s: webui.request - %s %s %d %.3fms user=%s request_id=%s remote_ip=%s\n",
+		strings.ToUpper(entry.Level), entry.Method, entry.Path, entry.Status, entry.DurationMS, entry.User,
+		entry.RequestID, entry.RemoteIP))
+}
+
+func (l *requestLogger) writeDebug(entry *requestDebugEntry) {
+	if l.format == RequestLogJSON {
+		l.writeJSON(entry)
+		return
+	}
+	l.writeLine(fmt.Sprintf("DEBUG: webui.request - %s %s query=%q user_agent=%q request_id=%s\n",
+		entry.Method, entry.Path, entry.Query, entry.UserAgent, entry.RequestID))
+}
+
+func (l *requestLogger) writePanic(entry *requestPanicEntry) {
+	if l.format == RequestLogJSON {
+		l.writeJSON(entry)
+		return
+	}
+	l.writeLine(fmt.Sprintf("ERROR: webui.panic - %s %s request_id=%s - %s\n%s\n",
+		entry.Method, entry.Path, entry.RequestID, entry.Panic, entry.Stack))
+}
+
+func (l *requestLogger) writeJSON(entry interface{}) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.writeLine(string(b) + "\n")
+}
+
+func (l *requestLogger) writeLine(line string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	io.WriteString(l.out, line)
+}
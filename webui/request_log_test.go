@@ -0,0 +1,76 @@
+package webui
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIRequestLogJSON(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	var buf bytes.Buffer
+	s := NewServer(ns, pool, ":6666", "", "", WithRequestLog(&buf, RequestLogJSON))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/queues?page=1", nil)
+	request.RemoteAddr = "10.0.0.1:51234"
+	request.SetBasicAuth("alice", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/job/nope/state", nil)
+	request.RemoteAddr = "10.0.0.2:51234"
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Equal(t, 2, len(lines)) {
+		var entry requestLogEntry
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		assert.Equal(t, "GET", entry.Method)
+		assert.Equal(t, "/queues", entry.Path)
+		assert.Equal(t, 200, entry.Status)
+		assert.True(t, entry.DurationMS >= 0)
+		assert.Equal(t, "alice", entry.User)
+		assert.NotEqual(t, "", entry.RequestID)
+		assert.Equal(t, "10.0.0.1", entry.RemoteIP)
+
+		var fields map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &fields))
+		assert.EqualValues(t, 404, fields["status"])
+		assert.Equal(t, "", fields["user"])
+		assert.Equal(t, "10.0.0.2", fields["remote_ip"])
+		for _, field := range []string{"method", "path", "status", "duration_ms", "user", "request_id", "remote_ip"} {
+			assert.Contains(t, fields, field)
+		}
+	}
+}
+
+func TestWebUIRequestLogText(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	var buf bytes.Buffer
+	s := NewServer(ns, pool, ":6666", "", "", WithRequestLog(&buf, RequestLogText))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/queues", nil)
+	request.RemoteAddr = "10.0.0.1:51234"
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	line := buf.String()
+	assert.True(t, strings.HasPrefix(line, "INFO: webui.request - GET /queues 200 "), line)
+	assert.Contains(t, line, "request_id="+recorder.Header().Get(requestIDHeader))
+	assert.Contains(t, line, "remote_ip=10.0.0.1\n")
+}
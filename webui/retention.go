@@ -0,0 +1,88 @@
+package webui
+
+import (
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/web"
+)
+
+// retentionPolicy limits how long and how many dead jobs are kept. A zero limit isn't enforced.
+type retentionPolicy struct {
+	MaxAgeSecs int64 `json:"max_age_secs"`
+	MaxCount   int64 `json:"max_count"`
+}
+
+// retentionPolicy loads the policy, which is kept in redis so that every webui instance enforces the same one.
+func (w *Server) retentionPolicy() (*retentionPolicy, error) {
+	conn := w.pool.Get()
+	defer conn.Close()
+
+	fields, err := redis.Int64Map(conn.Do("HGETALL", redisKeyRetention(w.namespace)))
+	if err != nil {
+		return nil, err
+	}
+	return &retentionPolicy{MaxAgeSecs: fields["max_age_secs"], MaxCount: fields["max_count"]}, nil
+}
+
+// enforceRetention is run by the sampler to delete the dead jobs beyond the retention policy. Any webui instance can
+// run it; deleting jobs that another instance already deleted is a no-op.
+func (w *Server) enforceRetention() {
+	policy, err := w.retentionPolicy()
+	if err != nil || (policy.MaxAgeSecs == 0 && policy.MaxCount == 0) {
+		return
+	}
+
+	var diedBefore int64
+	if policy.MaxAgeSecs > 0 {
+		diedBefore = nowEpochSeconds() - policy.MaxAgeSecs
+	}
+	w.client.PruneDeadJobs(diedBefore, policy.MaxCount)
+}
+
+// retention returns the dead job retention policy.
+func (c *context) retention(rw web.ResponseWriter, r *web.Request) {
+	policy, err := c.retentionPolicy()
+	render(rw, policy, err)
+}
+
+// setRetention updates the dead job retention policy from the max_age_secs and max_count form fields, and returns
+// it. A field that's left out keeps its current value, and 0 removes that limit. The sampler enforces the policy from
+// its next run.
+func (c *context) setRetention(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	args := redis.Args{redisKeyRetention(c.namespace)}
+	for _, field := range []string{"max_age_secs", "max_count"} {
+		vStr := r.Form.Get(field)
+		if vStr == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(vStr, 10, 64)
+		if err != nil {
+			renderError(rw, badParam(field, err))
+			return
+		}
+		if v < 0 {
+			renderError(rw, badRequestError(field+" can't be negative"))
+			return
+		}
+		args = args.Add(field, v)
+	}
+
+	if len(args) > 1 {
+		conn := c.pool.Get()
+		_, err := conn.Do("HMSET", args...)
+		conn.Close()
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+	}
+
+	policy, err := c.retentionPolicy()
+	render(rw, policy, err)
+}
@@ -0,0 +1,71 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIRetention(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	for _, diedAt := range []int64{1425263009, 1425263109, 1425263309, 1425263359, 1425263409} {
+		insertDeadJob(ns, pool, "wat", diedAt-10, diedAt)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	setRetention := func(form url.Values) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/retention", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	deadCount := func() int64 {
+		_, count, err := s.client.DeadJobs(1)
+		assert.NoError(t, err)
+		return count
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/retention", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"max_age_secs": 0, "max_count": 0, "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+
+	// Without a policy, the reaper keeps everything
+	s.enforceRetention()
+	assert.EqualValues(t, 5, deadCount())
+
+	// Jobs that died more than 200 seconds ago are removed
+	recorder = setRetention(url.Values{"max_age_secs": {"200"}})
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"max_age_secs": 200, "max_count": 0, "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+	s.enforceRetention()
+	assert.EqualValues(t, 3, deadCount())
+
+	// Updating the count keeps the age limit
+	recorder = setRetention(url.Values{"max_count": {"2"}})
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{"max_age_secs": 200, "max_count": 2, "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+	s.enforceRetention()
+	jobs, count, err := s.client.DeadJobs(1)
+	assert.NoError(t, err)
+	if assert.EqualValues(t, 2, count) {
+		assert.EqualValues(t, 1425263359, jobs[0].DiedAt)
+		assert.EqualValues(t, 1425263409, jobs[1].DiedAt)
+	}
+
+	recorder = setRetention(url.Values{"max_count": {"-1"}})
+	assert.Equal(t, 400, recorder.Code)
+}
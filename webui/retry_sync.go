@@ -0,0 +1,79 @@
+package webui
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocraft/web"
+)
+
+const (
+	defaultRetrySyncWait = 5 * time.Second
+	maxRetrySyncWait     = 30 * time.Second
+	retrySyncPollEvery   = 100 * time.Millisecond
+)
+
+// retryDeadJobSync retries a dead job and then watches its queue for up to wait_secs seconds (5 by default, at most
+// 30), reporting whether a worker picked the job up in that time. It's meant for one-off retries while testing. While
+// it waits it doesn't count towards WithMaxInFlight's limit or the redis pool admission.
+func (c *context) retryDeadJobSync(rw web.ResponseWriter, r *web.Request) {
+	diedAt, err := strconv.ParseInt(r.PathParams["died_at"], 10, 64)
+	if err != nil {
+		renderError(rw, badParam("died_at", err))
+		return
+	}
+
+	wait := defaultRetrySyncWait
+	if waitStr := r.URL.Query().Get("wait_secs"); waitStr != "" {
+		secs, err := strconv.ParseUint(waitStr, 10, 0)
+		if err != nil {
+			renderError(rw, badParam("wait_secs", err))
+			return
+		}
+		wait = time.Duration(secs) * time.Second
+	}
+	if wait > maxRetrySyncWait {
+		wait = maxRetrySyncWait
+	}
+
+	job, err := c.client.FindDeadJob(diedAt, r.PathParams["job_id"])
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	if job == nil {
+		renderErrorCode(rw, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+
+	if err := c.client.RetryDeadJob(diedAt, job.ID); err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := struct {
+		Status   string `json:"status"`
+		PickedUp bool   `json:"picked_up"`
+	}{Status: "ok"}
+
+	deadline := time.Now().Add(wait)
+	for {
+		queued, err := c.client.FindQueuedJob(job.Name, job.ID)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		if queued == nil {
+			response.PickedUp = true
+			break
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(retrySyncPollEvery)
+	}
+
+	render(rw, response, nil)
+}
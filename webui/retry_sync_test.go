@@ -0,0 +1,64 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIRetryDeadJobSync(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	retrySync := func(job *work.Job, query string) (int, bool) {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", fmt.Sprintf("/dead_job/%d/%s/retry_sync?%s", job.FailedAt, job.ID, query), nil)
+		s.router.ServeHTTP(recorder, request)
+
+		var res struct {
+			PickedUp bool `json:"picked_up"`
+		}
+		if recorder.Code == 200 {
+			err := json.Unmarshal(recorder.Body.Bytes(), &res)
+			assert.NoError(t, err)
+		}
+		return recorder.Code, res.PickedUp
+	}
+
+	// Nothing processes wat yet, so it's still queued when the wait is over
+	job := insertDeadJob(ns, pool, "wat", 1, 10)
+	code, pickedUp := retrySync(job, "wait_secs=0")
+	assert.Equal(t, 200, code)
+	assert.False(t, pickedUp)
+
+	client := work.NewClient(ns, pool)
+	queued, err := client.FindQueuedJob("wat", job.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, queued)
+
+	// It's not dead anymore
+	code, _ = retrySync(job, "wait_secs=0")
+	assert.Equal(t, 404, code)
+
+	wp := work.NewWorkerPool(TestContext{}, 2, ns, pool)
+	wp.Job("wat", func(job *work.Job) error {
+		return nil
+	})
+	wp.Start()
+	defer wp.Stop()
+	// Let it process the job retried above first, so that it isn't dequeued while the retry below reads the queues
+	wp.Drain()
+
+	job = insertDeadJob(ns, pool, "wat", 2, 20)
+	code, pickedUp = retrySync(job, "wait_secs=5")
+	assert.Equal(t, 200, code)
+	assert.True(t, pickedUp)
+}
@@ -0,0 +1,106 @@
+package webui
+
+import (
+	"sync"
+	"time"
+)
+
+// sampler runs a set of sample functions on a fixed interval in a single background goroutine. Features that need to
+// watch redis over time (queue depths, throughput, etc) register a sample function rather than starting their own loop.
+type sampler struct {
+	samples   []func()
+	newTicker func(time.Duration) (<-chan time.Time, func())
+
+	// mtx guards interval and started, so that the interval can be changed while the loop runs.
+	mtx              sync.Mutex
+	interval         time.Duration
+	started          bool
+	intervalChan     chan time.Duration
+	stopChan         chan struct{}
+	doneStoppingChan chan struct{}
+}
+
+func newSampler(interval time.Duration) *sampler {
+	return &sampler{
+		interval:         interval,
+		newTicker:        newTimeTicker,
+		intervalChan:     make(chan time.Duration),
+		stopChan:         make(chan struct{}),
+		doneStoppingChan: make(chan struct{}),
+	}
+}
+
+func newTimeTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// add registers fn to be run on every tick. It must be called before start.
+func (s *sampler) add(fn func()) {
+	s.samples = append(s.samples, fn)
+}
+
+func (s *sampler) start() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+	go s.loop(s.interval)
+}
+
+func (s *sampler) stop() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.started {
+		return
+	}
+	s.started = false
+	s.stopChan <- struct{}{}
+	<-s.doneStoppingChan
+}
+
+// currentInterval returns how often the samples run.
+func (s *sampler) currentInterval() time.Duration {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.interval
+}
+
+// setInterval changes how often the samples run. If the loop is running, the next sample is interval from now.
+func (s *sampler) setInterval(interval time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.interval = interval
+	if s.started {
+		s.intervalChan <- interval
+	}
+}
+
+func (s *sampler) loop(interval time.Duration) {
+	tickChan, stopTicker := s.newTicker(interval)
+	defer func() { stopTicker() }()
+
+	for {
+		select {
+		case <-s.stopChan:
+			s.doneStoppingChan <- struct{}{}
+			return
+		case interval := <-s.intervalChan:
+			stopTicker()
+			tickChan, stopTicker = s.newTicker(interval)
+		case <-tickChan:
+			s.sample()
+		}
+	}
+}
+
+func (s *sampler) sample() {
+	for _, fn := range s.samples {
+		fn()
+	}
+}
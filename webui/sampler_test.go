@@ -0,0 +1,82 @@
+package webui
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	var samples int64
+	tickChan := make(chan time.Time)
+
+	s := newSampler(5 * time.Second)
+	s.newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		assert.Equal(t, 5*time.Second, d)
+		return tickChan, func() {}
+	}
+	s.add(func() { atomic.AddInt64(&samples, 1) })
+	s.start()
+
+	// Sends are unbuffered so each one waits for the previous sample to finish; stop waits for the last one.
+	tickChan <- time.Now()
+	tickChan <- time.Now()
+	tickChan <- time.Now()
+	s.stop()
+
+	assert.EqualValues(t, 3, atomic.LoadInt64(&samples))
+
+	// Once stopped, nobody is listening for ticks anymore.
+	select {
+	case tickChan <- time.Now():
+		t.Error("sampler loop still running after stop")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Stopping again is a no-op.
+	s.stop()
+}
+
+func TestSamplerStopWithoutStart(t *testing.T) {
+	s := newSampler(time.Second)
+	s.stop()
+}
+
+func TestSamplerSetInterval(t *testing.T) {
+	var samples int64
+	type ticker struct {
+		interval time.Duration
+		tickChan chan time.Time
+	}
+	tickers := make(chan ticker, 2)
+
+	s := newSampler(5 * time.Second)
+	s.newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		tickChan := make(chan time.Time)
+		tickers <- ticker{interval: d, tickChan: tickChan}
+		return tickChan, func() {}
+	}
+	s.add(func() { atomic.AddInt64(&samples, 1) })
+
+	// Before starting, it's just recorded
+	s.setInterval(3 * time.Second)
+	assert.Equal(t, 3*time.Second, s.currentInterval())
+
+	s.start()
+	first := <-tickers
+	assert.Equal(t, 3*time.Second, first.interval)
+
+	s.setInterval(time.Second)
+	assert.Equal(t, time.Second, s.currentInterval())
+
+	// The loop now waits on a ticker with the new interval
+	second := <-tickers
+	assert.Equal(t, time.Second, second.interval)
+	second.tickChan <- time.Now()
+	second.tickChan <- time.Now()
+	s.stop()
+
+	assert.EqualValues(t, 2, atomic.LoadInt64(&samples))
+}
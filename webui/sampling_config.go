@@ -0,0 +1,66 @@
+package webui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gocraft/web"
+)
+
+// The sampler interval can only be set within these bounds through /sampling_config. Sampling too often puts load on
+// redis, and too rarely makes the sampled stats meaningless.
+const (
+	minSamplerInterval = time.Second
+	maxSamplerInterval = 10 * time.Minute
+)
+
+type samplingConfig struct {
+	IntervalSecs float64 `json:"interval_secs"`
+}
+
+// samplingConfig returns how often the background samplers run.
+func (c *context) samplingConfig(rw web.ResponseWriter, r *web.Request) {
+	render(rw, &samplingConfig{IntervalSecs: c.sampler.currentInterval().Seconds()}, nil)
+}
+
+// setSamplingConfig changes how often the background samplers run from the interval_secs form field, taking effect
+// right away. The interval must be between 1 second and 10 minutes. The change lasts until the server restarts.
+func (c *context) setSamplingConfig(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	secs, err := strconv.ParseFloat(r.Form.Get("interval_secs"), 64)
+	if err != nil {
+		renderError(rw, badParam("interval_secs", err))
+		return
+	}
+
+	interval := time.Duration(secs * float64(time.Second))
+	if interval < minSamplerInterval || interval > maxSamplerInterval {
+		renderError(rw, badRequestError(fmt.Sprintf("interval_secs must be between %v and %v", minSamplerInterval.Seconds(), maxSamplerInterval.Seconds())))
+		return
+	}
+
+	c.sampler.setInterval(interval)
+
+	render(rw, &samplingConfig{IntervalSecs: interval.Seconds()}, nil)
+}
+
+// resetSamplers clears everything the background samplers have recorded, for a clean slate when testing. It returns
+// the number of series cleared: one per queue with enqueue rate samples, per job tracked for /recovered_jobs or
+// /slowest_jobs, per job name with error counts for /slo/error_rate, and per job name tracked by the alert watcher. It's only served with WithSamplerReset.
+func (c *context) resetSamplers(rw web.ResponseWriter, r *web.Request) {
+	cleared := c.Server.queueRates.reset() + c.recoveries.reset() + c.errorCounts.reset() + c.completions.reset()
+	if c.alertWatcher != nil {
+		cleared += c.alertWatcher.reset()
+	}
+
+	response := struct {
+		SeriesCleared int `json:"series_cleared"`
+	}{SeriesCleared: cleared}
+
+	render(rw, response, nil)
+}
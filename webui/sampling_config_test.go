@@ -0,0 +1,113 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUISamplingConfig(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "", WithSamplerInterval(time.Minute))
+	s.sampler.start()
+	defer s.sampler.stop()
+
+	get := func() float64 {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/sampling_config", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res samplingConfig
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		return res.IntervalSecs
+	}
+
+	set := func(secs string) int {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/sampling_config", strings.NewReader("interval_secs="+secs))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	assert.EqualValues(t, 60, get())
+
+	assert.Equal(t, 200, set("2.5"))
+	assert.EqualValues(t, 2.5, get())
+	assert.Equal(t, 2500*time.Millisecond, s.sampler.currentInterval())
+
+	assert.Equal(t, 400, set("0.01"))
+	assert.Equal(t, 400, set("3600"))
+	assert.EqualValues(t, 2.5, get())
+}
+
+func TestWebUIResetSamplers(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	enqueuer := work.NewEnqueuer(ns, pool)
+	_, err := enqueuer.Enqueue("wat", nil)
+	assert.NoError(t, err)
+	_, err = enqueuer.Enqueue("foo", nil)
+	assert.NoError(t, err)
+	insertDeadJob(ns, pool, "bar", 1, 10)
+
+	// It's only served when enabled
+	s := NewServer(ns, pool, ":6666", "", "")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/samplers/reset", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+
+	s = NewServer(ns, pool, ":6666", "", "", WithSamplerReset())
+	s.sampleQueueRates()
+	s.sampleRecoveries()
+	setNowEpochSecondsMock(1425263419)
+	s.sampleQueueRates()
+	s.sampleRecoveries()
+
+	get := func(path string) string {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		return recorder.Body.String()
+	}
+	assert.Contains(t, get("/queues/rates"), `"job_name": "wat"`)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/samplers/reset", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var res struct {
+		SeriesCleared int `json:"series_cleared"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	// 3 queues, and the dead job tracked for recoveries
+	assert.Equal(t, 4, res.SeriesCleared)
+
+	assert.Contains(t, get("/queues/rates"), `"rates": []`)
+	assert.Contains(t, get("/recovered_jobs"), `"count": 0`)
+
+	// Deleting the dead job now isn't seen as a recovery, since the samplers start over
+	conn := pool.Get()
+	_, err = conn.Do("DEL", ns+":dead")
+	assert.NoError(t, err)
+	conn.Close()
+	s.sampleRecoveries()
+	assert.Contains(t, get("/recovered_jobs"), `"count": 0`)
+}
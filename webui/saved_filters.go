@@ -0,0 +1,98 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/web"
+)
+
+// filterableEndpoints are the list endpoints a saved filter can be applied to.
+var filterableEndpoints = map[string]bool{
+	"dead_jobs":  true,
+	"retry_jobs": true,
+}
+
+// savedFilter is a named set of query params for one of the list endpoints, shared by everyone using the namespace.
+type savedFilter struct {
+	Name     string            `json:"name"`
+	Endpoint string            `json:"endpoint"`
+	Params   map[string]string `json:"params"`
+}
+
+func (c *context) savedFilters(rw web.ResponseWriter, r *web.Request) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("HVALS", redisKeySavedFilters(c.namespace)))
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	filters := make([]*savedFilter, 0, len(values))
+	for _, v := range values {
+		var f savedFilter
+		if err := json.Unmarshal(v, &f); err != nil {
+			renderError(rw, err)
+			return
+		}
+		filters = append(filters, &f)
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Name < filters[j].Name })
+
+	render(rw, filters, nil)
+}
+
+func (c *context) saveFilter(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	f := &savedFilter{
+		Name:     r.Form.Get("name"),
+		Endpoint: r.Form.Get("endpoint"),
+		Params:   map[string]string{},
+	}
+	if f.Name == "" {
+		renderError(rw, badRequestError("name is required"))
+		return
+	}
+	if !filterableEndpoints[f.Endpoint] {
+		renderError(rw, badRequestError(fmt.Sprintf("can't save a filter for endpoint %q", f.Endpoint)))
+		return
+	}
+
+	query, err := url.ParseQuery(r.Form.Get("query"))
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	for k := range query {
+		f.Params[k] = query.Get(k)
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("HSET", redisKeySavedFilters(c.namespace), f.Name, b)
+	render(rw, f, err)
+}
+
+func (c *context) deleteSavedFilter(rw web.ResponseWriter, r *web.Request) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HDEL", redisKeySavedFilters(c.namespace), r.PathParams["name"])
+	render(rw, &statusResponse{Status: "ok"}, err)
+}
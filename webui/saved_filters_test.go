@@ -0,0 +1,64 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUISavedFilters(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	form := url.Values{}
+	form.Set("name", "triage")
+	form.Set("endpoint", "dead_jobs")
+	form.Set("query", "status=unreviewed&page=2")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/saved_filters", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	form.Set("name", "bad")
+	form.Set("endpoint", "queues")
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/saved_filters", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.router.ServeHTTP(recorder, request)
+	assert.NotEqual(t, 200, recorder.Code)
+
+	var res []savedFilter
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/saved_filters", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(res)) {
+		assert.Equal(t, "triage", res[0].Name)
+		assert.Equal(t, "dead_jobs", res[0].Endpoint)
+		assert.Equal(t, map[string]string{"status": "unreviewed", "page": "2"}, res[0].Params)
+	}
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/delete_saved_filter/triage", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/saved_filters", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(res))
+}
@@ -0,0 +1,77 @@
+package webui
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+// exportBatchSize is how many jobs are read from redis at a time by the export endpoints, which bounds their memory
+// use however many jobs there are.
+const exportBatchSize = 500
+
+// scheduledJobExport is a line of /scheduled_jobs/export. Periodic is whether the job was scheduled by a worker pool's
+// periodic job, and PeriodicSpec is that job's cron spec.
+type scheduledJobExport struct {
+	*work.ScheduledJob
+	Args         json.RawMessage `json:"args"`
+	Periodic     bool            `json:"periodic"`
+	PeriodicSpec string          `json:"periodic_spec,omitempty"`
+}
+
+// exportScheduledJobs streams every scheduled job, soonest first, as newline-delimited JSON. Jobs are read in batches,
+// so a job that's scheduled or runs while the export is in progress may be skipped or exported twice.
+func (c *context) exportScheduledJobs(rw web.ResponseWriter, r *web.Request) {
+	rw.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(rw)
+
+	for page := uint(1); ; page++ {
+		jobs, _, err := c.readClient.ScheduledJobsPage(page, exportBatchSize)
+		if err != nil {
+			if page == 1 {
+				rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+				renderError(rw, err)
+			}
+			// Otherwise the response has started, so it's cut short instead
+			return
+		}
+
+		for _, j := range jobs {
+			args, err := j.ArgsJSON()
+			if err != nil {
+				return
+			}
+			spec, periodic := periodicSpec(j.Job)
+			if err := enc.Encode(&scheduledJobExport{ScheduledJob: j, Args: args, Periodic: periodic, PeriodicSpec: spec}); err != nil {
+				return
+			}
+		}
+		rw.Flush()
+
+		if len(jobs) < exportBatchSize {
+			return
+		}
+	}
+}
+
+// periodicSpec returns the cron spec of the periodic job that scheduled job, if it was scheduled by one. The periodic
+// enqueuer gives jobs IDs of the form "periodic:<job name>:<spec>:<epoch>".
+func periodicSpec(job *work.Job) (string, bool) {
+	prefix := "periodic:" + job.Name + ":"
+	if !strings.HasPrefix(job.ID, prefix) {
+		return "", false
+	}
+
+	rest := job.ID[len(prefix):]
+	i := strings.LastIndex(rest, ":")
+	if i < 1 {
+		return "", false
+	}
+	if _, err := strconv.ParseInt(rest[i+1:], 10, 64); err != nil {
+		return "", false
+	}
+	return rest[:i], true
+}
@@ -0,0 +1,86 @@
+package webui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodicSpec(t *testing.T) {
+	cases := []struct {
+		id       string
+		spec     string
+		periodic bool
+	}{
+		{"periodic:wat:0 */5 * * * *:1425263700", "0 */5 * * * *", true},
+		{"periodic:wat:@every 1m:1425263700", "@every 1m", true},
+		{"periodic:foo:0 */5 * * * *:1425263700", "", false},
+		{"periodic:wat::1425263700", "", false},
+		{"periodic:wat:0 */5 * * * *:soon", "", false},
+		{"c0ffee", "", false},
+	}
+	for _, c := range cases {
+		spec, periodic := periodicSpec(&work.Job{Name: "wat", ID: c.id})
+		assert.Equal(t, c.spec, spec, c.id)
+		assert.Equal(t, c.periodic, periodic, c.id)
+	}
+}
+
+func TestWebUIExportScheduledJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	// More than a batch of jobs, plus one scheduled by a periodic job
+	for i := 0; i <= exportBatchSize; i++ {
+		conn.Send("ZADD", ns+":scheduled", 2000+i, fmt.Sprintf(`{"name":"wat","id":"job-%d","t":1000,"args":{"i":%d}}`, i, i))
+	}
+	conn.Send("ZADD", ns+":scheduled", 1500, `{"name":"wat","id":"periodic:wat:0 */5 * * * *:1500","t":1500}`)
+	assert.NoError(t, conn.Flush())
+	for i := 0; i <= exportBatchSize+1; i++ {
+		_, err := conn.Receive()
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/scheduled_jobs/export", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+
+	type line struct {
+		ID           string                 `json:"id"`
+		RunAt        int64                  `json:"run_at"`
+		Args         map[string]interface{} `json:"args"`
+		Periodic     bool                   `json:"periodic"`
+		PeriodicSpec string                 `json:"periodic_spec"`
+	}
+	var lines []line
+	scanner := bufio.NewScanner(strings.NewReader(recorder.Body.String()))
+	for scanner.Scan() {
+		var l line
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &l))
+		lines = append(lines, l)
+	}
+
+	if assert.Equal(t, exportBatchSize+2, len(lines)) {
+		assert.Equal(t, line{ID: "periodic:wat:0 */5 * * * *:1500", RunAt: 1500, Periodic: true, PeriodicSpec: "0 */5 * * * *"}, lines[0])
+		for i, l := range lines[1:] {
+			assert.Equal(t, fmt.Sprintf("job-%d", i), l.ID)
+			assert.EqualValues(t, 2000+i, l.RunAt)
+			assert.EqualValues(t, i, l.Args["i"])
+			assert.False(t, l.Periodic)
+		}
+	}
+}
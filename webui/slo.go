@@ -0,0 +1,172 @@
+package webui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gocraft/web"
+)
+
+const (
+	defaultSLOWindow = 60 * 60
+	// maxSLOWindow is how far back the processed and failed counts are remembered, in seconds.
+	maxSLOWindow = 24 * 60 * 60
+)
+
+// counterSample is the processed and failed counts by job name, as of a sample.
+type counterSample struct {
+	at        int64
+	processed map[string]int64
+	failed    map[string]int64
+}
+
+// errorCounts keeps the samples of the processed and failed counts from the last maxSLOWindow seconds, oldest first,
+// so that the counts over a window can be found by comparing the latest sample with the one at its start.
+type errorCounts struct {
+	mtx     sync.Mutex
+	samples []*counterSample
+}
+
+// sloErrorRate is how many jobs were processed and failed over a window, and the fraction that failed.
+type sloErrorRate struct {
+	JobName   string  `json:"job_name,omitempty"`
+	Processed int64   `json:"processed"`
+	Failed    int64   `json:"failed"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// sampleErrorCounts is run by the sampler to record the processed and failed counts.
+func (w *Server) sampleErrorCounts() {
+	processed, err := w.readClient.ProcessedCounts()
+	if err != nil {
+		return
+	}
+	failed, err := w.readClient.FailedCounts()
+	if err != nil {
+		return
+	}
+
+	w.errorCounts.observe(&counterSample{at: nowEpochSeconds(), processed: processed, failed: failed})
+}
+
+// observe records a sample, and forgets the samples that are no longer needed to cover the longest window.
+func (ec *errorCounts) observe(sample *counterSample) {
+	ec.mtx.Lock()
+	defer ec.mtx.Unlock()
+
+	ec.samples = append(ec.samples, sample)
+
+	// Keep the newest sample from before the longest window, since it's where that window's counts start from
+	cutoff := sample.at - maxSLOWindow
+	first := 0
+	for i, s := range ec.samples {
+		if s.at <= cutoff {
+			first = i
+		}
+	}
+	ec.samples = ec.samples[first:]
+}
+
+// over returns the processed and failed counts over the window seconds before the latest sample, by job name and
+// overall, along with the times of the samples they're taken between. If the samples don't go back that far, the counts
+// are over the time they cover. With fewer than two samples, the counts are all zero.
+func (ec *errorCounts) over(window int64) ([]*sloErrorRate, *sloErrorRate, int64, int64) {
+	ec.mtx.Lock()
+	defer ec.mtx.Unlock()
+
+	rates := []*sloErrorRate{}
+	overall := &sloErrorRate{}
+	if len(ec.samples) < 2 {
+		return rates, overall, 0, 0
+	}
+
+	latest := ec.samples[len(ec.samples)-1]
+	start := ec.samples[0]
+	for _, s := range ec.samples {
+		if s.at <= latest.at-window {
+			start = s
+		}
+	}
+
+	for jobName, processed := range latest.processed {
+		rate := &sloErrorRate{
+			JobName:   jobName,
+			Processed: counterDelta(processed, start.processed[jobName]),
+			Failed:    counterDelta(latest.failed[jobName], start.failed[jobName]),
+		}
+		rate.ErrorRate = errorRate(rate.Processed, rate.Failed)
+		overall.Processed += rate.Processed
+		overall.Failed += rate.Failed
+		rates = append(rates, rate)
+	}
+	overall.ErrorRate = errorRate(overall.Processed, overall.Failed)
+
+	sort.Slice(rates, func(i, j int) bool {
+		return rates[i].JobName < rates[j].JobName
+	})
+	return rates, overall, start.at, latest.at
+}
+
+// reset forgets the samples. It returns the number of job names they covered.
+func (ec *errorCounts) reset() int {
+	ec.mtx.Lock()
+	defer ec.mtx.Unlock()
+
+	n := 0
+	if len(ec.samples) > 0 {
+		n = len(ec.samples[len(ec.samples)-1].processed)
+	}
+	ec.samples = nil
+	return n
+}
+
+// counterDelta is how much a counter grew from before to after. A counter that went down was reset in between, so
+// it's treated as not having grown.
+func counterDelta(after, before int64) int64 {
+	if after < before {
+		return 0
+	}
+	return after - before
+}
+
+// errorRate is the fraction of processed jobs that failed, or 0 if none were processed.
+func errorRate(processed, failed int64) float64 {
+	if processed == 0 {
+		return 0
+	}
+	return float64(failed) / float64(processed)
+}
+
+// sloErrorRate returns how many jobs were processed and how many failed over the last window_secs seconds (an hour by
+// default, at most a day), per queue and overall, and the resulting error rates, eg for SLO burn rate calculations.
+// The counts come from the sampler, so they're only as fresh as its last run, and only cover the time since the server
+// started; from and to are the times of the samples they're taken between. A retried job counts once per attempt.
+func (c *context) sloErrorRate(rw web.ResponseWriter, r *web.Request) {
+	window := int64(defaultSLOWindow)
+	if windowStr := r.URL.Query().Get("window_secs"); windowStr != "" {
+		var err error
+		window, err = strconv.ParseInt(windowStr, 10, 64)
+		if err != nil {
+			renderError(rw, badParam("window_secs", err))
+			return
+		}
+		if window < 1 || window > maxSLOWindow {
+			renderError(rw, badRequestError(fmt.Sprintf("window_secs must be between 1 and %d", maxSLOWindow)))
+			return
+		}
+	}
+
+	queues, overall, from, to := c.errorCounts.over(window)
+
+	response := struct {
+		WindowSecs int64           `json:"window_secs"`
+		From       int64           `json:"from"`
+		To         int64           `json:"to"`
+		Overall    *sloErrorRate   `json:"overall"`
+		Queues     []*sloErrorRate `json:"queues"`
+	}{WindowSecs: window, From: from, To: to, Overall: overall, Queues: queues}
+
+	render(rw, response, nil)
+}
@@ -0,0 +1,109 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCountsOver(t *testing.T) {
+	var ec errorCounts
+	ec.observe(&counterSample{at: 100, processed: map[string]int64{"wat": 10}, failed: map[string]int64{}})
+	ec.observe(&counterSample{at: 110, processed: map[string]int64{"wat": 20, "foo": 4}, failed: map[string]int64{"wat": 1}})
+	ec.observe(&counterSample{at: 120, processed: map[string]int64{"wat": 40, "foo": 8}, failed: map[string]int64{"wat": 3, "foo": 4}})
+
+	queues, overall, from, to := ec.over(10)
+	assert.EqualValues(t, 110, from)
+	assert.EqualValues(t, 120, to)
+	assert.Equal(t, []*sloErrorRate{
+		{JobName: "foo", Processed: 4, Failed: 4, ErrorRate: 1},
+		{JobName: "wat", Processed: 20, Failed: 2, ErrorRate: 0.1},
+	}, queues)
+	assert.Equal(t, &sloErrorRate{Processed: 24, Failed: 6, ErrorRate: 0.25}, overall)
+
+	// A window longer than the samples cover is counted from the oldest
+	_, overall, from, _ = ec.over(60)
+	assert.EqualValues(t, 100, from)
+	assert.Equal(t, &sloErrorRate{Processed: 38, Failed: 7, ErrorRate: 7.0 / 38}, overall)
+
+	// Samples are forgotten once they're no longer the start of the longest window
+	ec.observe(&counterSample{at: 110 + maxSLOWindow, processed: map[string]int64{"wat": 40}, failed: map[string]int64{}})
+	assert.Len(t, ec.samples, 3)
+	assert.EqualValues(t, 110, ec.samples[0].at)
+
+	assert.Equal(t, 1, ec.reset())
+	queues, overall, from, to = ec.over(10)
+	assert.Empty(t, queues)
+	assert.Equal(t, &sloErrorRate{}, overall)
+	assert.EqualValues(t, 0, from)
+	assert.EqualValues(t, 0, to)
+}
+
+func TestWebUISLOErrorRate(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	type response struct {
+		WindowSecs int64           `json:"window_secs"`
+		From       int64           `json:"from"`
+		To         int64           `json:"to"`
+		Overall    *sloErrorRate   `json:"overall"`
+		Queues     []*sloErrorRate `json:"queues"`
+	}
+	get := func(path string) *response {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+		var res response
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		return &res
+	}
+	setCounts := func(key string, counts ...interface{}) {
+		conn := pool.Get()
+		defer conn.Close()
+		_, err := conn.Do("HSET", append([]interface{}{ns + ":" + key}, counts...)...)
+		assert.NoError(t, err)
+	}
+
+	// Zeros until there's something to compare
+	res := get("/slo/error_rate")
+	assert.EqualValues(t, defaultSLOWindow, res.WindowSecs)
+	assert.Equal(t, &sloErrorRate{}, res.Overall)
+	assert.Empty(t, res.Queues)
+
+	setCounts("processed", "wat", 100, "foo", 10)
+	setCounts("failed", "wat", 5)
+	s.sampleErrorCounts()
+
+	setNowEpochSecondsMock(1425263469)
+	setCounts("processed", "wat", 200, "foo", 20)
+	setCounts("failed", "wat", 15, "foo", 5)
+	s.sampleErrorCounts()
+
+	res = get("/slo/error_rate?window_secs=60")
+	assert.EqualValues(t, 60, res.WindowSecs)
+	assert.EqualValues(t, 1425263409, res.From)
+	assert.EqualValues(t, 1425263469, res.To)
+	assert.Equal(t, []*sloErrorRate{
+		{JobName: "foo", Processed: 10, Failed: 5, ErrorRate: 0.5},
+		{JobName: "wat", Processed: 100, Failed: 10, ErrorRate: 0.1},
+	}, res.Queues)
+	assert.Equal(t, &sloErrorRate{Processed: 110, Failed: 15, ErrorRate: 15.0 / 110}, res.Overall)
+
+	for _, path := range []string{"/slo/error_rate?window_secs=0", "/slo/error_rate?window_secs=wat"} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 400, recorder.Code, path)
+	}
+}
@@ -0,0 +1,161 @@
+package webui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+const (
+	defaultSlowestJobsLimit = 10
+	defaultSlowestWindow    = 60 * 60
+	// maxSlowestWindow is how long completed jobs are remembered, in seconds.
+	maxSlowestWindow = 24 * 60 * 60
+)
+
+// completedJob is a job that a worker was seen running in one sample and no longer running in a later one.
+type completedJob struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	StartedAt    int64  `json:"started_at"`
+	CompletedAt  int64  `json:"completed_at"`
+	DurationSecs int64  `json:"duration_secs"`
+}
+
+// completions reconstructs how long jobs ran from samples of the worker observations. Nothing records when a job
+// finishes, so a job counts as completed at the first sample that no longer sees it running, whether it succeeded or
+// not. That makes durations an overestimate of up to a sampler interval, and jobs that start and finish between two
+// samples aren't seen at all.
+type completions struct {
+	mtx sync.Mutex
+	// running is the jobs being run at the last sample, by ID and start time.
+	running   map[string]*work.WorkerObservation
+	completed []*completedJob
+}
+
+// sampleCompletions is run by the sampler to look for jobs that have finished running.
+func (w *Server) sampleCompletions() {
+	observations, err := w.readClient.WorkerObservations()
+	if err != nil {
+		return
+	}
+	w.completions.observe(nowEpochSeconds(), observations)
+}
+
+// observe records a sample of the worker observations taken at now.
+func (cs *completions) observe(now int64, observations []*work.WorkerObservation) {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	// A retried job runs again with the same ID, so its start time tells the runs apart
+	running := map[string]*work.WorkerObservation{}
+	for _, o := range observations {
+		if o.IsBusy {
+			running[fmt.Sprintf("%s:%d", o.JobID, o.StartedAt)] = o
+		}
+	}
+
+	for key, o := range cs.running {
+		if running[key] != nil {
+			continue
+		}
+		cs.completed = append(cs.completed, &completedJob{
+			ID:           o.JobID,
+			Name:         o.JobName,
+			StartedAt:    o.StartedAt,
+			CompletedAt:  now,
+			DurationSecs: now - o.StartedAt,
+		})
+	}
+
+	completed := cs.completed[:0]
+	for _, j := range cs.completed {
+		if j.CompletedAt >= now-maxSlowestWindow {
+			completed = append(completed, j)
+		}
+	}
+	cs.completed = completed
+
+	cs.running = running
+}
+
+// slowest returns the limit jobs that took longest among those that completed at or after t, slowest first. Jobs that
+// took as long are ordered by when they completed, most recent first.
+func (cs *completions) slowest(t int64, limit int) []*completedJob {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	jobs := []*completedJob{}
+	for _, j := range cs.completed {
+		if j.CompletedAt >= t {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].DurationSecs != jobs[j].DurationSecs {
+			return jobs[i].DurationSecs > jobs[j].DurationSecs
+		}
+		return jobs[i].CompletedAt > jobs[j].CompletedAt
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs
+}
+
+// reset forgets the running and completed jobs seen so far. It returns the number of jobs it was tracking.
+func (cs *completions) reset() int {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	n := len(cs.running) + len(cs.completed)
+	cs.running = nil
+	cs.completed = nil
+	return n
+}
+
+// slowestJobs lists the jobs that took longest among those that completed within the last window_secs seconds (an
+// hour by default, at most a day), as reconstructed by the sampler. The limit param caps how many are returned; it
+// defaults to 10 and can be at most the max page size. It only covers the time since the server started.
+func (c *context) slowestJobs(rw web.ResponseWriter, r *web.Request) {
+	limit := uint64(defaultSlowestJobsLimit)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.ParseUint(limitStr, 10, 0)
+		if err != nil {
+			renderError(rw, badParam("limit", err))
+			return
+		}
+		if limit == 0 || limit > uint64(c.config.maxPageSize) {
+			renderError(rw, badRequestError(fmt.Sprintf("limit must be between 1 and %d", c.config.maxPageSize)))
+			return
+		}
+	}
+
+	window := int64(defaultSlowestWindow)
+	if windowStr := r.URL.Query().Get("window_secs"); windowStr != "" {
+		var err error
+		window, err = strconv.ParseInt(windowStr, 10, 64)
+		if err != nil {
+			renderError(rw, badParam("window_secs", err))
+			return
+		}
+		if window < 1 || window > maxSlowestWindow {
+			renderError(rw, badRequestError(fmt.Sprintf("window_secs must be between 1 and %d", maxSlowestWindow)))
+			return
+		}
+	}
+
+	jobs := c.completions.slowest(nowEpochSeconds()-window, int(limit))
+
+	response := struct {
+		WindowSecs int64           `json:"window_secs"`
+		Jobs       []*completedJob `json:"jobs"`
+	}{WindowSecs: window, Jobs: jobs}
+
+	render(rw, response, nil)
+}
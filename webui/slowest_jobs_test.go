@@ -0,0 +1,94 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionsObserve(t *testing.T) {
+	busy := func(id string, startedAt int64) *work.WorkerObservation {
+		return &work.WorkerObservation{WorkerID: "w-" + id, IsBusy: true, JobName: "wat", JobID: id, StartedAt: startedAt}
+	}
+	idle := &work.WorkerObservation{WorkerID: "w-idle"}
+
+	var cs completions
+	cs.observe(100, []*work.WorkerObservation{busy("a", 90), busy("b", 95), idle})
+	assert.Empty(t, cs.slowest(0, 10))
+
+	// a finished, and b was retried after failing
+	cs.observe(110, []*work.WorkerObservation{busy("b", 105), busy("c", 108)})
+	assert.Equal(t, []*completedJob{
+		{ID: "a", Name: "wat", StartedAt: 90, CompletedAt: 110, DurationSecs: 20},
+		{ID: "b", Name: "wat", StartedAt: 95, CompletedAt: 110, DurationSecs: 15},
+	}, cs.slowest(0, 10))
+
+	cs.observe(120, []*work.WorkerObservation{})
+	jobs := cs.slowest(0, 10)
+	if assert.Len(t, jobs, 4) {
+		assert.Equal(t, "a", jobs[0].ID)
+		// The retry took as long, but completed more recently
+		assert.EqualValues(t, 105, jobs[1].StartedAt)
+		assert.Equal(t, "c", jobs[3].ID)
+	}
+	assert.Len(t, cs.slowest(0, 1), 1)
+	assert.Len(t, cs.slowest(115, 10), 2)
+
+	// Completions are forgotten after the longest window
+	cs.observe(121+maxSlowestWindow, nil)
+	assert.Empty(t, cs.slowest(0, 10))
+}
+
+func TestWebUISlowestJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	var running []*work.WorkerObservation
+	for i, duration := range []int64{30, 300, 5, 120} {
+		running = append(running, &work.WorkerObservation{
+			WorkerID:  string(rune('a' + i)),
+			IsBusy:    true,
+			JobName:   "wat",
+			JobID:     string(rune('a' + i)),
+			StartedAt: 1425263409 - duration,
+		})
+	}
+	s.completions.observe(1425263409, running)
+	s.completions.observe(1425263409, nil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := get("/slowest_jobs?limit=3&window_secs=60")
+	assert.Equal(t, 200, recorder.Code)
+	var res struct {
+		WindowSecs int64           `json:"window_secs"`
+		Jobs       []*completedJob `json:"jobs"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.EqualValues(t, 60, res.WindowSecs)
+	var durations []int64
+	for _, j := range res.Jobs {
+		durations = append(durations, j.DurationSecs)
+	}
+	assert.Equal(t, []int64{300, 120, 30}, durations)
+	assert.Equal(t, "b", res.Jobs[0].ID)
+
+	for _, path := range []string{"/slowest_jobs?limit=0", "/slowest_jobs?window_secs=nope"} {
+		assert.Equal(t, 400, get(path).Code, path)
+	}
+}
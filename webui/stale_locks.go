@@ -0,0 +1,36 @@
+package webui
+
+import (
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+// staleLocks lists the locks of unique jobs stuck in progress in dead worker pools. Until they expire, they keep the
+// same jobs from being enqueued again.
+func (c *context) staleLocks(rw web.ResponseWriter, r *web.Request) {
+	locks, err := c.readClient.StaleLocks()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := struct {
+		Count int               `json:"count"`
+		Locks []*work.StaleLock `json:"locks"`
+	}{Count: len(locks), Locks: locks}
+
+	render(rw, response, nil)
+}
+
+// clearStaleLocks releases the locks listed by staleLocks. The jobs stay in progress for the dead pool reaper (or
+// /worker_pool/:pool_id/requeue_inflight, with WithRequeueInFlight) to requeue.
+func (c *context) clearStaleLocks(rw web.ResponseWriter, r *web.Request) {
+	cleared, err := c.client.ClearStaleLocks()
+
+	response := struct {
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}{Status: "ok", Count: cleared}
+
+	render(rw, response, err)
+}
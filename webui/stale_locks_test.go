@@ -0,0 +1,154 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/work"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIStaleLocks(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SADD", ns+":worker_pools", "crashed", "alive")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:crashed", "heartbeat_at", time.Now().Add(-time.Hour).Unix(), "job_names", "wat")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:alive", "heartbeat_at", time.Now().Unix(), "job_names", "wat")
+	assert.NoError(t, err)
+
+	// Each worker pool took a unique job off the queue, and crashed didn't get to release its lock
+	enqueuer := work.NewEnqueuer(ns, pool)
+	stuck, err := enqueuer.EnqueueUnique("wat", work.Q{"a": 1})
+	assert.NoError(t, err)
+	_, err = conn.Do("RPOPLPUSH", ns+":jobs:wat", ns+":jobs:wat:crashed:inprogress")
+	assert.NoError(t, err)
+	_, err = enqueuer.EnqueueUnique("wat", work.Q{"a": 2})
+	assert.NoError(t, err)
+	_, err = conn.Do("RPOPLPUSH", ns+":jobs:wat", ns+":jobs:wat:alive:inprogress")
+	assert.NoError(t, err)
+	// Jobs that aren't unique have no lock
+	_, err = enqueuer.Enqueue("wat", work.Q{"a": 3})
+	assert.NoError(t, err)
+	_, err = conn.Do("RPOPLPUSH", ns+":jobs:wat", ns+":jobs:wat:crashed:inprogress")
+	assert.NoError(t, err)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	list := func() []*work.StaleLock {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/stale_locks", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res struct {
+			Count int               `json:"count"`
+			Locks []*work.StaleLock `json:"locks"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		assert.Equal(t, len(res.Locks), res.Count)
+		return res.Locks
+	}
+
+	locks := list()
+	if assert.Equal(t, 1, len(locks)) {
+		assert.Equal(t, "crashed", locks[0].WorkerPoolID)
+		assert.Equal(t, "wat", locks[0].JobName)
+		assert.Equal(t, stuck.ID, locks[0].JobID)
+	}
+	staleKey := locks[0].Key
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/stale_locks/clear", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var res struct {
+		Count int64 `json:"count"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.Count)
+
+	exists, err := redis.Bool(conn.Do("EXISTS", staleKey))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, 0, len(list()))
+
+	// The live pool's lock is kept, and the job can be enqueued again
+	dup, err := enqueuer.EnqueueUnique("wat", work.Q{"a": 2})
+	assert.NoError(t, err)
+	assert.Nil(t, dup)
+	_, err = enqueuer.EnqueueUnique("wat", work.Q{"a": 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:wat"))
+	assert.EqualValues(t, 2, listSize(pool, ns+":jobs:wat:crashed:inprogress"))
+}
+
+func TestWebUIStaleLocksReenqueued(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SADD", ns+":worker_pools", "crashed")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:crashed", "heartbeat_at", time.Now().Add(-time.Hour).Unix(), "job_names", "wat")
+	assert.NoError(t, err)
+
+	// crashed released the lock when it started on the job, and then the job was enqueued again
+	enqueuer := work.NewEnqueuer(ns, pool)
+	stuck, err := enqueuer.EnqueueUnique("wat", work.Q{"a": 1})
+	assert.NoError(t, err)
+	_, err = conn.Do("RPOPLPUSH", ns+":jobs:wat", ns+":jobs:wat:crashed:inprogress")
+	assert.NoError(t, err)
+	_, err = conn.Do("DEL", ns+":unique:wat:{\"a\":1}\n")
+	assert.NoError(t, err)
+	again, err := enqueuer.EnqueueUnique("wat", work.Q{"a": 1})
+	assert.NoError(t, err)
+	if assert.NotNil(t, again) {
+		assert.NotEqual(t, stuck.ID, again.ID)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/stale_locks", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var locks struct {
+		Count int `json:"count"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &locks)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, locks.Count)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/stale_locks/clear", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var res struct {
+		Count int64 `json:"count"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, res.Count)
+
+	// The lock still belongs to the queued copy, so no duplicate gets through
+	dup, err := enqueuer.EnqueueUnique("wat", work.Q{"a": 1})
+	assert.NoError(t, err)
+	assert.Nil(t, dup)
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:wat"))
+}
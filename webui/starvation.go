@@ -0,0 +1,91 @@
+package webui
+
+import (
+	"sort"
+
+	"github.com/gocraft/web"
+)
+
+// starvationShare is the fraction of its fair share of the dequeue rate below which a backlogged queue is flagged as
+// starving.
+const starvationShare = 0.5
+
+// queueStarvation is how a queue's sampled dequeue rate compares with its fair share of the namespace's dequeue rate.
+type queueStarvation struct {
+	JobName     string  `json:"job_name"`
+	Count       int64   `json:"count"`
+	Priority    uint    `json:"priority"`
+	DequeueRate float64 `json:"dequeue_rate"`
+	FairRate    float64 `json:"fair_rate"`
+	Share       float64 `json:"share"`
+	Starving    bool    `json:"starving"`
+}
+
+// queueStarvations returns the fairness of each queue's processing over the last sampler interval. Workers pick among
+// the queues that have jobs in proportion to their priorities, so a backlogged queue's fair rate is the total dequeue
+// rate of all queues times its priority over the summed priority of the backlogged queues. A queue's share is its
+// dequeue rate over its fair rate, and a backlogged queue whose share is under starvationShare is starving. Like
+// /queues/rates, it's only available once the sampler has run twice.
+func (c *context) queueStarvations(rw web.ResponseWriter, r *web.Request) {
+	heartbeats, err := c.readClient.WorkerPoolHeartbeats()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	// Pools serving the same job usually agree on its priority; if they don't, the highest wins. Jobs only served by
+	// pools that don't publish priorities get the default of 1.
+	priorities := map[string]uint{}
+	for _, hb := range heartbeats {
+		for jobName, priority := range hb.JobPriorities {
+			if priority > priorities[jobName] {
+				priorities[jobName] = priority
+			}
+		}
+	}
+
+	qr := &c.Server.queueRates
+	qr.mtx.Lock()
+	response := struct {
+		SampledAt int64              `json:"sampled_at"`
+		Queues    []*queueStarvation `json:"queues"`
+	}{
+		SampledAt: qr.sampledAt,
+		Queues:    make([]*queueStarvation, 0, len(qr.dequeueRates)),
+	}
+	var totalRate float64
+	var backloggedPriority uint
+	for jobName, rate := range qr.dequeueRates {
+		qs := &queueStarvation{
+			JobName:     jobName,
+			Count:       qr.depths[jobName],
+			Priority:    priorities[jobName],
+			DequeueRate: rate,
+		}
+		if qs.Priority == 0 {
+			qs.Priority = 1
+		}
+		totalRate += rate
+		if qs.Count > 0 {
+			backloggedPriority += qs.Priority
+		}
+		response.Queues = append(response.Queues, qs)
+	}
+	qr.mtx.Unlock()
+
+	for _, qs := range response.Queues {
+		// With nothing processed anywhere, every queue is stalled rather than starved by the others
+		if qs.Count == 0 || totalRate == 0 {
+			continue
+		}
+		qs.FairRate = totalRate * float64(qs.Priority) / float64(backloggedPriority)
+		qs.Share = qs.DequeueRate / qs.FairRate
+		qs.Starving = qs.Share < starvationShare
+	}
+
+	sort.Slice(response.Queues, func(i, j int) bool {
+		return response.Queues[i].JobName < response.Queues[j].JobName
+	})
+
+	render(rw, response, nil)
+}
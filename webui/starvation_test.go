@@ -0,0 +1,107 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIQueueStarvation(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	conn := pool.Get()
+	defer conn.Close()
+	do := func(args ...interface{}) {
+		_, err := conn.Do(args[0].(string), args[1:]...)
+		assert.NoError(t, err)
+	}
+	do("SADD", ns+":worker_pools", "1")
+	do("HMSET", ns+":worker_pools:1", "heartbeat_at", 1425263409, "job_names", "high,low,idle", "job_priorities", "high:3,low:1,idle:1")
+	for _, jobName := range []string{"high", "low", "idle"} {
+		do("SADD", ns+":known_jobs", jobName)
+	}
+	for i := 0; i < 200; i++ {
+		do("LPUSH", ns+":jobs:high", `{"name":"high","id":"x","t":1}`)
+		do("LPUSH", ns+":jobs:low", `{"name":"low","id":"x","t":1}`)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	type queue struct {
+		JobName     string  `json:"job_name"`
+		Count       int64   `json:"count"`
+		Priority    uint    `json:"priority"`
+		DequeueRate float64 `json:"dequeue_rate"`
+		FairRate    float64 `json:"fair_rate"`
+		Share       float64 `json:"share"`
+		Starving    bool    `json:"starving"`
+	}
+	getStarvation := func() map[string]queue {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/queues/starvation", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res struct {
+			Queues []queue `json:"queues"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		queues := map[string]queue{}
+		for _, q := range res.Queues {
+			queues[q.JobName] = q
+		}
+		return queues
+	}
+
+	// A single sample isn't enough to know a rate
+	s.sampleQueueRates()
+	assert.Equal(t, 0, len(getStarvation()))
+
+	// Over 10 seconds, workers took 95 high jobs and 5 low ones. Out of the 10/s total, high's fair share is 7.5/s and
+	// low's is 2.5/s.
+	skew := func(high, low int) {
+		for i := 0; i < high; i++ {
+			do("RPOP", ns+":jobs:high")
+		}
+		for i := 0; i < low; i++ {
+			do("RPOP", ns+":jobs:low")
+		}
+		do("HINCRBY", ns+":processed", "high", high)
+		do("HINCRBY", ns+":processed", "low", low)
+	}
+	skew(95, 5)
+	setNowEpochSecondsMock(1425263419)
+	s.sampleQueueRates()
+
+	queues := getStarvation()
+	assert.Equal(t, queue{JobName: "high", Count: 105, Priority: 3, DequeueRate: 9.5, FairRate: 7.5, Share: 9.5 / 7.5}, queues["high"])
+	assert.Equal(t, queue{JobName: "low", Count: 195, Priority: 1, DequeueRate: 0.5, FairRate: 2.5, Share: 0.2, Starving: true}, queues["low"])
+	// An empty queue can't starve
+	assert.Equal(t, queue{JobName: "idle", Priority: 1}, queues["idle"])
+
+	// Processing in proportion to the priorities is fair
+	skew(75, 25)
+	setNowEpochSecondsMock(1425263429)
+	s.sampleQueueRates()
+
+	queues = getStarvation()
+	assert.False(t, queues["high"].Starving)
+	assert.False(t, queues["low"].Starving)
+	assert.InDelta(t, 1, queues["low"].Share, 0.001)
+
+	// When nothing is processed at all, queues are stalled rather than starved
+	setNowEpochSecondsMock(1425263439)
+	s.sampleQueueRates()
+
+	queues = getStarvation()
+	assert.False(t, queues["low"].Starving)
+	assert.EqualValues(t, 0, queues["low"].FairRate)
+}
@@ -0,0 +1,65 @@
+package webui
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/gocraft/web"
+	"github.com/gocraft/work"
+)
+
+// summaryText is a short plain text report of the namespace's queue depths, dead jobs, and stale worker pools, meant
+// to be embedded in alerts. A pool is stale once it's gone work.DeadPoolTime without a heartbeat.
+func (c *context) summaryText(rw web.ResponseWriter, r *web.Request) {
+	queues, err := c.readClient.Queues()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	_, deadCount, err := c.readClient.DeadJobs(1)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	heartbeats, err := c.readClient.WorkerPoolHeartbeats()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "work namespace %s\n", c.namespace)
+
+	var queued int64
+	for _, q := range queues {
+		queued += q.Count
+	}
+	fmt.Fprintf(&buf, "queued jobs: %d\n", queued)
+	for _, q := range queues {
+		if q.Count > 0 {
+			fmt.Fprintf(&buf, "  %s: %d (oldest %ds)\n", q.JobName, q.Count, q.Latency)
+		}
+	}
+
+	fmt.Fprintf(&buf, "dead jobs: %d\n", deadCount)
+
+	now := nowEpochSeconds()
+	var stale []string
+	for _, hb := range heartbeats {
+		if hb.HeartbeatAt == 0 {
+			stale = append(stale, fmt.Sprintf("  %s: no heartbeat\n", hb.WorkerPoolID))
+		} else if age := now - hb.HeartbeatAt; age > int64(work.DeadPoolTime/time.Second) {
+			stale = append(stale, fmt.Sprintf("  %s: last heartbeat %ds ago\n", hb.WorkerPoolID, age))
+		}
+	}
+	fmt.Fprintf(&buf, "worker pools: %d, stale: %d\n", len(heartbeats), len(stale))
+	for _, line := range stale {
+		buf.WriteString(line)
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Write(buf.Bytes())
+}
@@ -0,0 +1,55 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUISummaryText(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	insertQueuedJob(ns, pool, "wat", 1425263409-30)
+	insertQueuedJob(ns, pool, "wat", 1425263409-20)
+	insertQueuedJob(ns, pool, "foo", 1425263409-10)
+	insertDeadJob(ns, pool, "wat", 1, 10)
+	insertDeadJob(ns, pool, "bar", 1, 11)
+	insertDeadJob(ns, pool, "bar", 1, 12)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":worker_pools", "alive", "crashed", "gone")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:alive", "heartbeat_at", 1425263409-5)
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:crashed", "heartbeat_at", 1425263409-3600)
+	assert.NoError(t, err)
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/summary.txt", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	summary := recorder.Body.String()
+	assert.Contains(t, summary, "work namespace testwork\n")
+	assert.Contains(t, summary, "queued jobs: 3\n")
+	// The latency comes from the client, which doesn't use the mocked time
+	assert.Contains(t, summary, "  wat: 2 (oldest ")
+	assert.Contains(t, summary, "  foo: 1 (oldest ")
+	assert.NotContains(t, summary, "  bar:")
+	assert.Contains(t, summary, "dead jobs: 3\n")
+	assert.Contains(t, summary, "worker pools: 3, stale: 2\n")
+	assert.Contains(t, summary, "  crashed: last heartbeat 3600s ago\n")
+	assert.Contains(t, summary, "  gone: no heartbeat\n")
+	assert.NotContains(t, summary, "alive")
+}
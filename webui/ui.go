@@ -0,0 +1,79 @@
+//go:build !noui
+// +build !noui
+
+package webui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gocraft/web"
+	"github.com/zier/work/webui/internal/assets"
+)
+
+// uiCompiledIn is whether the bundled HTML UI is part of the binary.
+const uiCompiledIn = true
+
+// registerAssetRoutes serves the bundled HTML UI, and returns the paths it's served on. Build with the noui tag to
+// leave the UI and its assets out of the binary.
+func registerAssetRoutes(router *web.Router, cfg *config) []string {
+	//
+	// Build the HTML page:
+	//
+	index := assets.MustAsset("index.html")
+	if cfg.assetBaseURL != "" {
+		index = rebaseAssetURLs(index, cfg.assetBaseURL)
+	} else if cfg.pathPrefix != "" {
+		index = rebaseAssetURLs(index, cfg.pathPrefix)
+	}
+	if cfg.pathPrefix != "" {
+		index = injectPathPrefix(index, cfg.pathPrefix)
+	}
+
+	router.Get("/", func(c *context, rw web.ResponseWriter, req *web.Request) {
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write(index)
+	})
+	router.Get("/work.js", func(c *context, rw web.ResponseWriter, req *web.Request) {
+		rw.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+		rw.Write(assets.MustAsset("work.js"))
+	})
+
+	return []string{"/", "/work.js"}
+}
+
+// rebaseAssetURLs rewrites the root-relative script and stylesheet URLs in html to point under baseURL instead.
+func rebaseAssetURLs(html []byte, baseURL string) []byte {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for _, attr := range []string{`src="/`, `href="/`} {
+		rebased := attr[:len(attr)-1] + baseURL + "/"
+		html = bytes.Replace(html, []byte(attr), []byte(rebased), -1)
+	}
+	return html
+}
+
+// pathPrefixScript sets the workPathPrefix global to the path prefix, and makes fetch prepend it to the root-relative
+// URLs work.js calls the API at. The bundled work.js is prebuilt, so the prefix is applied around it rather than read
+// by it.
+const pathPrefixScript = `<script>
+window.workPathPrefix = %s;
+(function (fetch) {
+  window.fetch = function (url, init) {
+    if (typeof url === "string" && url.charAt(0) === "/") {
+      url = window.workPathPrefix + url;
+    }
+    return fetch.call(this, url, init);
+  };
+})(window.fetch);
+</script>
+`
+
+// injectPathPrefix adds pathPrefixScript for prefix to the head of html, so that the UI calls the API under prefix.
+func injectPathPrefix(html []byte, prefix string) []byte {
+	// Marshaling escapes <, > and &, so the prefix can't end the script early
+	encoded, _ := json.Marshal(prefix)
+	script := fmt.Sprintf(pathPrefixScript, encoded)
+	return bytes.Replace(html, []byte("</head>"), []byte(script+"</head>"), 1)
+}
@@ -0,0 +1,17 @@
+//go:build noui
+// +build noui
+
+package webui
+
+import (
+	"github.com/gocraft/web"
+)
+
+// uiCompiledIn is whether the bundled HTML UI is part of the binary. It isn't when building with the noui tag, in
+// which case the server behaves as if WithoutUI was given.
+const uiCompiledIn = false
+
+// registerAssetRoutes is never called when the UI isn't compiled in.
+func registerAssetRoutes(router *web.Router, cfg *config) []string {
+	return nil
+}
@@ -0,0 +1,171 @@
+//go:build !noui
+// +build !noui
+
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIAssets(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServer(ns, pool, ":6666", "admin", "secret")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	body := string(recorder.Body.Bytes())
+	assert.Regexp(t, "html", body)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/work.js", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+
+	assert.Contains(t, s.routes, route{Method: "GET", Path: "/work.js"})
+}
+
+func TestWebUIAssetsWithoutCredentials(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	// With no credentials configured, nothing asks for any
+	for _, path := range []string{"/", "/work.js", "/queues"} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code, path)
+		assert.Empty(t, recorder.Header().Get("WWW-Authenticate"), path)
+	}
+
+	// Setting either one still requires auth
+	s = NewServer(ns, pool, ":6666", "admin", "")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 401, recorder.Code)
+}
+
+func TestWebUIAssetsPathPrefix(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServerWithOptions(ns, pool, ":6666", WithBasicAuth("admin", "secret"), WithPathPrefix("/work"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/work/", nil)
+	s.server.Handler.ServeHTTP(recorder, request)
+	assert.Equal(t, 401, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request.SetBasicAuth("admin", "secret")
+	s.server.Handler.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `src="/work/work.js"`)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/work/work.js", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.server.Handler.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+}
+
+func TestWebUIAssetsBearerToken(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServerWithOptions(ns, pool, ":6666", WithBearerToken("s3cr3t"))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 401, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request.Header.Set("Authorization", "Bearer s3cr3t")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+}
+
+func TestWebUIPathPrefixUI(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+	s := NewServerWithOptions(ns, pool, ":6666", WithPathPrefix("/ui"))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.Handler().ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, 200, get("/ui/queues").Code)
+	assert.Equal(t, 404, get("/queues").Code)
+
+	// The page tells the UI's API calls where to go
+	recorder := get("/ui/")
+	assert.Equal(t, 200, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `window.workPathPrefix = "/ui";`)
+	assert.Contains(t, body, `src="/ui/work.js"`)
+	assert.True(t, strings.Index(body, "workPathPrefix") < strings.Index(body, "work.js"), "the prefix is set before work.js runs")
+
+	// Without a prefix the page is left as it is
+	s = NewServerWithOptions(ns, pool, ":6666")
+	assert.NotContains(t, get("/").Body.String(), "workPathPrefix")
+}
+
+func TestInjectPathPrefix(t *testing.T) {
+	html := injectPathPrefix([]byte("<head></head>"), "/</script>")
+	assert.Contains(t, string(html), `window.workPathPrefix = "/\u003c/script\u003e";`)
+}
+
+func TestWebUIAssetsClientCertAuth(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServer(ns, pool, ":6666", "admin", "secret", WithClientCertAuth("billing-service"))
+
+	// The certificate takes the place of basic auth
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	request.TLS = verifiedCertState("billing-service")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Regexp(t, "html", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/", nil)
+	request.TLS = verifiedCertState("intruder")
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 403, recorder.Code)
+}
+
+func TestWebUIAssetBaseURL(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "admin", "secret")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Contains(t, recorder.Body.String(), `<script src="/work.js">`)
+
+	s = NewServer(ns, pool, ":6666", "admin", "secret", WithAssetBaseURL("https://cdn.example.com/work/v1/"))
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `<script src="https://cdn.example.com/work/v1/work.js">`)
+	assert.NotContains(t, recorder.Body.String(), `src="/work.js"`)
+}
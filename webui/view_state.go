@@ -0,0 +1,108 @@
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/gocraft/web"
+)
+
+// viewableEndpoints are the list endpoints a view can be saved for.
+var viewableEndpoints = map[string]bool{
+	"dead_jobs":      true,
+	"retry_jobs":     true,
+	"scheduled_jobs": true,
+	"failing_jobs":   true,
+}
+
+// viewState is a snapshot of how someone is looking at one of the list endpoints, saved so that it can be shared by
+// its ID. Sort is stored as given for the UI to interpret.
+type viewState struct {
+	ID       string            `json:"id"`
+	Endpoint string            `json:"endpoint"`
+	Filters  map[string]string `json:"filters"`
+	PageSize uint              `json:"page_size,omitempty"`
+	Sort     string            `json:"sort,omitempty"`
+}
+
+// viewState loads the view with the id param. It 404s if there's no such view.
+func (c *context) viewState(rw web.ResponseWriter, r *web.Request) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	b, err := redis.Bytes(conn.Do("HGET", redisKeyViewStates(c.namespace), r.URL.Query().Get("id")))
+	if err == redis.ErrNil {
+		renderErrorCode(rw, http.StatusNotFound, errors.New("view not found"))
+		return
+	} else if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	var v viewState
+	if err := json.Unmarshal(b, &v); err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	render(rw, &v, nil)
+}
+
+// saveViewState saves a view from the endpoint, filters (a query string), page_size, and sort form fields, and
+// returns it with the ID to share.
+func (c *context) saveViewState(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	v := &viewState{
+		ID:       makeRandomID(),
+		Endpoint: r.Form.Get("endpoint"),
+		Filters:  map[string]string{},
+		Sort:     r.Form.Get("sort"),
+	}
+	if !viewableEndpoints[v.Endpoint] {
+		renderError(rw, badRequestError(fmt.Sprintf("can't save a view of endpoint %q", v.Endpoint)))
+		return
+	}
+
+	filters, err := url.ParseQuery(r.Form.Get("filters"))
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	for k := range filters {
+		v.Filters[k] = filters.Get(k)
+	}
+
+	if sizeStr := r.Form.Get("page_size"); sizeStr != "" {
+		size, err := strconv.ParseUint(sizeStr, 10, 0)
+		if err != nil {
+			renderError(rw, badParam("page_size", err))
+			return
+		}
+		if size == 0 || size > uint64(c.config.maxPageSize) {
+			renderError(rw, badRequestError(fmt.Sprintf("page_size must be between 1 and %d", c.config.maxPageSize)))
+			return
+		}
+		v.PageSize = uint(size)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("HSET", redisKeyViewStates(c.namespace), v.ID, b)
+	render(rw, v, err)
+}
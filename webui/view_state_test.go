@@ -0,0 +1,71 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUIViewState(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	save := func(form url.Values) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/view_state", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	form := url.Values{}
+	form.Set("endpoint", "dead_jobs")
+	form.Set("filters", "status=unreviewed&name=wat")
+	form.Set("page_size", "50")
+	form.Set("sort", "died_at:desc")
+	recorder := save(form)
+	assert.Equal(t, 200, recorder.Code)
+
+	var saved viewState
+	err := json.Unmarshal(recorder.Body.Bytes(), &saved)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", saved.ID)
+
+	recorder = httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/view_state?id="+saved.ID, nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var loaded viewState
+	err = json.Unmarshal(recorder.Body.Bytes(), &loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, viewState{
+		ID:       saved.ID,
+		Endpoint: "dead_jobs",
+		Filters:  map[string]string{"status": "unreviewed", "name": "wat"},
+		PageSize: 50,
+		Sort:     "died_at:desc",
+	}, loaded)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/view_state?id=nope", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+
+	form.Set("endpoint", "queues")
+	recorder = save(form)
+	assert.Equal(t, 400, recorder.Code)
+
+	form.Set("endpoint", "retry_jobs")
+	form.Set("page_size", "1000")
+	recorder = save(form)
+	assert.Equal(t, 400, recorder.Code)
+}
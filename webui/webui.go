@@ -1,44 +1,90 @@
 package webui
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/braintree/manners"
 	"github.com/garyburd/redigo/redis"
 	"github.com/gocraft/web"
 	"github.com/gocraft/work"
-	"github.com/zier/work/webui/internal/assets"
 )
 
 // Server implements an HTTP server which exposes a JSON API to view and manage gocraft/work items.
 type Server struct {
-	namespace string
-	pool      *redis.Pool
-	client    *work.Client
-	hostPort  string
-	server    *manners.GracefulServer
-	wg        sync.WaitGroup
-	router    *web.Router
+	namespace    string
+	pool         *redis.Pool
+	client       *work.Client
+	readClient   *work.Client
+	enqueuer     *work.Enqueuer
+	hostPort     string
+	server       *manners.GracefulServer
+	wg           sync.WaitGroup
+	router       *web.Router
+	startedAt    int64
+	sampler      *sampler
+	fanOut       *fanOut
+	jobNames     jobNameCache
+	queueRates   queueRates
+	admission    *admission
+	inFlight     *admission
+	recoveries   recoveries
+	errorCounts  errorCounts
+	completions  completions
+	deadEvents   deadJobEvents
+	alertWatcher *alertWatcher
+	config       *config
+	endpoints    []string
+	routes       []route
 }
 
+// route is a method and path the server's router handles.
+type route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Admin is the credentials AdminRequired accepts: basic auth with Username and Password, or, if Token is set, a bearer
+// token.
 type Admin struct {
 	Username string
 	Password string
+	Token    string
 }
 
 type context struct {
 	*Server
-	Admin *Admin
+	Admin     *Admin
+	requestID string
+	user      string // who the request authenticated as, if an auth middleware vouched for it
 }
 
+// AdminRequired only lets through requests whose basic auth credentials match the admin's, or that carry the admin's
+// bearer token if one is configured. The credentials are compared in constant time so that response timing doesn't
+// leak how much of them matched. With no admin credentials configured, every request is rejected rather than let
+// through unauthenticated.
 func (c *context) AdminRequired(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
 	rw.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	if c.Admin.Token != "" {
+		rw.Header().Add("WWW-Authenticate", `Bearer realm="Restricted"`)
+	}
 
 	s := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
 	if len(s) != 2 {
@@ -46,107 +92,402 @@ func (c *context) AdminRequired(rw web.ResponseWriter, r *web.Request, next web.
 		return
 	}
 
-	b, err := base64.StdEncoding.DecodeString(s[1])
-	if err != nil {
-		http.Error(rw, err.Error(), 401)
-		return
+	var ok bool
+	switch strings.ToLower(s[0]) {
+	case "basic":
+		b, err := base64.StdEncoding.DecodeString(s[1])
+		if err != nil {
+			http.Error(rw, err.Error(), 401)
+			return
+		}
+		ok = c.Admin.basicAuthOK(string(b))
+	case "bearer":
+		ok = c.Admin.Token != "" && subtle.ConstantTimeCompare([]byte(s[1]), []byte(c.Admin.Token)) == 1
 	}
-
-	pair := strings.SplitN(string(b), ":", 2)
-	if len(pair) != 2 {
+	if !ok {
 		http.Error(rw, "Not authorized", 401)
 		return
 	}
 
-	if pair[0] != c.Admin.Username && pair[1] != c.Admin.Password {
-		http.Error(rw, "Not authorized", 401)
-		return
+	next(rw, r)
+}
+
+// basicAuthOK is whether the decoded basic auth credentials, "username:password", match the admin's.
+func (a *Admin) basicAuthOK(credentials string) bool {
+	if a.Username == "" && a.Password == "" {
+		return false
 	}
 
-	next(rw, r)
+	pair := strings.SplitN(credentials, ":", 2)
+	if len(pair) != 2 {
+		return false
+	}
+
+	// Both are always compared, so that a wrong username takes as long to reject as a wrong password
+	usernameOK := subtle.ConstantTimeCompare([]byte(pair[0]), []byte(a.Username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(pair[1]), []byte(a.Password)) == 1
+	return usernameOK && passwordOK
+}
+
+// NewServer creates and returns a new server that requires basic auth with the username and password, unless both are empty. It's the same as NewServerWithOptions with WithBasicAuth(username, password) ahead of opts.
+func NewServer(namespace string, pool *redis.Pool, hostPort, username, password string, opts ...Option) *Server {
+	return NewServerWithOptions(namespace, pool, hostPort, append([]Option{WithBasicAuth(username, password)}, opts...)...)
 }
 
-// NewServer creates and returns a new server. The 'namespace' param is the redis namespace to use. The hostPort param is the address to bind on to expose the API.
-func NewServer(namespace string, pool *redis.Pool, hostPort, username, password string) *Server {
+// NewServerWithOptions creates and returns a new server. The 'namespace' param is the redis namespace to use, and can't be empty. The hostPort param is the address to bind on to expose the API. Optional behavior, such as auth, TLS and a path prefix, can be configured by passing Options.
+func NewServerWithOptions(namespace string, pool *redis.Pool, hostPort string, opts ...Option) *Server {
+	if namespace == "" {
+		panic("NewServer needs a non-empty namespace")
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	fanOutSize := cfg.fanOutSize
+	if fanOutSize < 1 {
+		fanOutSize = pool.MaxActive
+	}
+	if fanOutSize < 1 {
+		fanOutSize = runtime.GOMAXPROCS(0)
+	}
+
+	readPool := pool
+	if cfg.readPool != nil {
+		readPool = cfg.readPool
+	}
+
+	if cfg.checkConns {
+		checkConnections(pool, cfg.checkIdleAfter)
+		checkConnections(readPool, cfg.checkIdleAfter)
+	}
+
 	router := web.New(context{})
+	var handler http.Handler = router
+	if cfg.pathPrefix != "" {
+		handler = prefixHandler(cfg.pathPrefix, router)
+	}
 	server := &Server{
-		namespace: namespace,
-		pool:      pool,
-		client:    work.NewClient(namespace, pool),
-		hostPort:  hostPort,
-		server:    manners.NewWithServer(&http.Server{Addr: hostPort, Handler: router}),
-		router:    router,
+		namespace:  namespace,
+		pool:       pool,
+		client:     work.NewClient(namespace, pool),
+		readClient: work.NewClient(namespace, readPool),
+		enqueuer:   work.NewEnqueuer(namespace, pool),
+		hostPort:   hostPort,
+		server:     manners.NewWithServer(&http.Server{Addr: hostPort, Handler: handler}),
+		router:     router,
+		startedAt:  nowEpochSeconds(),
+		sampler:    newSampler(cfg.samplerInterval),
+		fanOut:     newFanOut(fanOutSize),
+		config:     cfg,
+	}
+
+	server.sampler.add(server.sampleQueueRates)
+	server.sampler.add(server.sampleRecoveries)
+	server.sampler.add(server.sampleErrorCounts)
+	server.sampler.add(server.sampleCompletions)
+	server.sampler.add(server.sampleDeadJobEvents)
+	server.sampler.add(server.enforceRetention)
+	if cfg.alertWebhookURL != "" {
+		server.alertWatcher = newAlertWatcher(cfg.alertWebhookURL)
+		server.sampler.add(server.watchAlerts)
 	}
 
+	admin := &Admin{Username: cfg.username, Password: cfg.password, Token: cfg.bearerToken}
 	router.Middleware(func(c *context, rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
 		c.Server = server
+		c.Admin = admin
 		next(rw, r)
 	})
+	if cfg.requestLog != nil {
+		router.Middleware((*context).logRequest)
+	}
+	router.Middleware((*context).assignRequestID)
+	router.Middleware((*context).setNamespace)
+	router.Middleware((*context).recoverPanic)
 	router.Middleware(func(rw web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
 		rw.Header().Set("Content-Type", "application/json; charset=utf-8")
 		next(rw, r)
 	})
-	router.Get("/queues", (*context).queues)
-	router.Get("/worker_pools", (*context).workerPools)
-	router.Get("/busy_workers", (*context).busyWorkers)
-	router.Get("/retry_jobs", (*context).retryJobs)
-	router.Get("/scheduled_jobs", (*context).scheduledJobs)
-	router.Get("/dead_jobs", (*context).deadJobs)
-	router.Post("/delete_dead_job/:died_at:\\d.*/:job_id", (*context).deleteDeadJob)
-	router.Post("/retry_dead_job/:died_at:\\d.*/:job_id", (*context).retryDeadJob)
-	router.Post("/delete_all_dead_jobs", (*context).deleteAllDeadJobs)
-	router.Post("/retry_all_dead_jobs", (*context).retryAllDeadJobs)
-
-	//
-	// Build the HTML page:
-	//
-	cx := context{
-		Admin: &Admin{
-			Username: username,
-			Password: password,
-		},
-	}
-	assetRouter := router.Subrouter(cx, "")
-	assetRouter.Middleware(cx.AdminRequired)
-	assetRouter.Get("/", func(c *context, rw web.ResponseWriter, req *web.Request) {
-		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
-		rw.Write(assets.MustAsset("index.html"))
-	})
-	assetRouter.Get("/work.js", func(c *context, rw web.ResponseWriter, req *web.Request) {
-		rw.Header().Set("Content-Type", "application/javascript; charset=utf-8")
-		rw.Write(assets.MustAsset("work.js"))
-	})
+	if cfg.maxInFlight > 0 {
+		server.inFlight = newAdmission(cfg.maxInFlight, cfg.inFlightWait, "too many requests in flight")
+		router.Middleware((*context).limitInFlight)
+	}
+	// With client certificate auth, the certificate takes the place of basic auth, and with no credentials everything is
+	// left open, eg for behind an authenticating proxy
+	if cfg.certSubjects != nil {
+		router.Middleware((*context).ClientCertRequired)
+	} else if cfg.hasCredentials() {
+		router.Middleware((*context).credentialsRequired)
+	}
+	if cfg.acquireTimeout > 0 && pool.MaxActive > 0 {
+		server.admission = newAdmission(pool.MaxActive, cfg.acquireTimeout, "redis connection pool exhausted")
+		router.Middleware((*context).admitRequest)
+	}
+	server.get(healthzPath, (*context).healthz)
+	server.get("/uptime", (*context).uptime)
+	server.get("/buildinfo", (*context).buildInfo)
+	server.get("/metrics", (*context).metrics)
+	server.get("/summary.txt", (*context).summaryText)
+	server.get("/queues", (*context).queues)
+	server.get("/queues/rates", (*context).queueRates)
+	server.get("/queues/starvation", (*context).queueStarvations)
+	server.get("/queue/:queue", (*context).queue)
+	if cfg.deadLetterQueue != "" {
+		server.post("/queue/:queue/to_deadletter", (*context).moveToDeadLetter)
+	}
+	server.post("/baseline", (*context).captureBaseline)
+	server.get("/baseline/diff", (*context).baselineDiff)
+	server.get("/oldest_pending", (*context).oldestPending)
+	server.get("/job_names/search", (*context).searchJobNames)
+	server.get("/cache/stats", (*context).cacheStats)
+	server.post("/cache/clear", (*context).clearCache)
+	server.get("/enqueue_histogram", (*context).enqueueHistogram)
+	server.get("/worker_pools", (*context).workerPools)
+	server.get("/namespace/concurrency", (*context).namespaceConcurrency)
+	server.get("/pool_stats", (*context).poolStats)
+	server.get("/jobs", (*context).knownJobs)
+	server.get("/busy_workers", (*context).busyWorkers)
+	server.post("/busy_workers/reap", (*context).reapBusyWorkers)
+	if cfg.requeueInFlight {
+		server.post("/worker_pool/:pool_id/requeue_inflight", (*context).requeueInFlight)
+	}
+	server.get("/stale_locks", (*context).staleLocks)
+	server.post("/stale_locks/clear", (*context).clearStaleLocks)
+	if cfg.reap {
+		server.post("/reap", (*context).reap)
+	}
+	server.get("/retry_jobs", (*context).retryJobs)
+	server.get("/retry_jobs/histogram", (*context).retryJobsHistogram)
+	server.get("/retry_jobs/exhausted", (*context).exhaustedRetryJobs)
+	server.get("/retry_jobs/most_retried", (*context).mostRetriedJobs)
+	server.get("/scheduled_jobs", (*context).scheduledJobs)
+	server.get("/scheduled_jobs/delays", (*context).scheduledJobDelays)
+	server.get("/scheduled_jobs/export", (*context).exportScheduledJobs)
+	server.get("/dead_jobs", (*context).deadJobs)
+	server.get("/failing_jobs", (*context).failingJobs)
+	server.get("/recovered_jobs", (*context).recoveredJobs)
+	server.get("/slowest_jobs", (*context).slowestJobs)
+	server.get(deadJobEventsPath, (*context).deadJobEventStream)
+	server.get("/slo/error_rate", (*context).sloErrorRate)
+	server.get("/job/:job_id/state", (*context).jobStateByID)
+	server.get("/job/:job_id/worker", (*context).jobWorker)
+	server.post("/job/:name/move_queue", (*context).moveJobQueue)
+	server.get("/dead_jobs/categories", (*context).deadJobCategories)
+	server.get("/dead_jobs/by_queue", (*context).deadJobsByQueue)
+	server.post("/dead_job/:died_at:\\d.*/:job_id/annotate", (*context).annotateDeadJob)
+	server.post("/dead_job/:died_at:\\d.*/:job_id/ack", (*context).ackDeadJob)
+	server.post("/dead_job/:died_at:\\d.*/:job_id/retry_sync", (*context).retryDeadJobSync)
+	server.post("/dead_jobs/ack_all", (*context).ackAllDeadJobs)
+	server.post("/dead_jobs/annotate", (*context).annotateDeadJobs)
+	server.post("/delete_dead_job/:died_at:\\d.*/:job_id", (*context).deleteDeadJob)
+	server.post("/retry_dead_job/:died_at:\\d.*/:job_id", (*context).retryDeadJob)
+	server.post("/delete_all_dead_jobs", (*context).deleteAllDeadJobs)
+	server.post("/retry_all_dead_jobs", (*context).retryAllDeadJobs)
+	server.post("/retry_all_retry_jobs/now", (*context).retryAllRetryJobsNow)
+	server.post("/enqueue/:job_name", (*context).enqueue)
+	server.get("/rate_limit", (*context).rateLimit)
+	server.post("/rate_limit", (*context).setRateLimit)
+	server.get("/sampling_config", (*context).samplingConfig)
+	server.post("/sampling_config", (*context).setSamplingConfig)
+	if cfg.samplerReset {
+		server.post("/samplers/reset", (*context).resetSamplers)
+	}
+	if cfg.requestLog != nil {
+		server.get("/log_level", (*context).logLevel)
+		server.post("/log_level", (*context).setLogLevel)
+	}
+	server.get("/retention", (*context).retention)
+	server.post("/retention", (*context).setRetention)
+	server.get("/alert_thresholds", (*context).alertThresholdsList)
+	server.post("/alert_thresholds", (*context).setAlertThreshold)
+	server.get("/saved_filters", (*context).savedFilters)
+	server.post("/saved_filters", (*context).saveFilter)
+	server.post("/delete_saved_filter/:name", (*context).deleteSavedFilter)
+	server.get("/view_state", (*context).viewState)
+	server.post("/view_state", (*context).saveViewState)
+	server.post("/batch", (*context).batch)
+	server.get("/routes", (*context).listRoutes)
+
+	if !cfg.disableUI && uiCompiledIn {
+		for _, path := range registerAssetRoutes(router, cfg) {
+			server.routes = append(server.routes, route{Method: "GET", Path: path})
+		}
+	} else if cfg.rootRedirect != "" {
+		router.Get("/", func(c *context, rw web.ResponseWriter, r *web.Request) {
+			http.Redirect(rw, r.Request, cfg.rootRedirect, http.StatusFound)
+		})
+		server.routes = append(server.routes, route{Method: "GET", Path: "/"})
+	} else {
+		router.Get("/", (*context).index)
+		server.routes = append(server.routes, route{Method: "GET", Path: "/"})
+	}
 
 	return server
 }
 
-// Start starts the server listening for requests on the hostPort specified in NewServer.
-func (w *Server) Start() {
+// routeParamPattern matches route params along with their regexp, eg ":died_at:\d.*", capturing the param name.
+var routeParamPattern = regexp.MustCompile(`(:\w+):[^/]*`)
+
+// get registers a GET route of the JSON API and lists it in the index served at / when the UI is disabled.
+func (w *Server) get(path string, fn interface{}) {
+	w.router.Get(path, fn)
+	w.addRoute("GET", path)
+}
+
+// post registers a POST route of the JSON API and lists it in the index served at / when the UI is disabled.
+func (w *Server) post(path string, fn interface{}) {
+	w.router.Post(path, fn)
+	w.addRoute("POST", path)
+}
+
+func (w *Server) addRoute(method, path string) {
+	path = routeParamPattern.ReplaceAllString(path, "$1")
+	w.endpoints = append(w.endpoints, method+" "+path)
+	w.routes = append(w.routes, route{Method: method, Path: path})
+}
+
+// index lists the endpoints of the JSON API. It's served at / when the UI is disabled and no redirect is configured.
+func (c *context) index(rw web.ResponseWriter, r *web.Request) {
+	response := struct {
+		Endpoints []string `json:"endpoints"`
+	}{Endpoints: c.endpoints}
+
+	render(rw, response, nil)
+}
+
+// listRoutes lists every method and path the server handles, including the UI's when it's enabled. Features that are
+// configured off, or left out of the build, aren't listed.
+func (c *context) listRoutes(rw web.ResponseWriter, r *web.Request) {
+	render(rw, c.routes, nil)
+}
+
+// Handler returns the server's HTTP handler, for mounting the UI and API in an existing server instead of calling Start, eg under "/admin/work/" with WithPathPrefix("/admin/work"). It starts the background samplers that feed endpoints such as /queues/rates, if they aren't running already; call Stop to stop them.
+func (w *Server) Handler() http.Handler {
+	w.sampler.start()
+	return w.server.Handler
+}
+
+// Start binds the hostPort specified in NewServerWithOptions and starts serving requests on it, along with the background samplers. It returns once the server is listening, or with the error if it can't listen, eg because the port is in use or WithTLS's certificate can't be loaded.
+func (w *Server) Start() error {
+	l, err := w.listen()
+	if err != nil {
+		return err
+	}
+
+	w.sampler.start()
 	w.wg.Add(1)
 	go func(w *Server) {
-		w.server.ListenAndServe()
+		w.server.Serve(l)
 		w.wg.Done()
 	}(w)
+	return nil
+}
+
+// listen binds the server's hostPort, serving HTTPS if TLS is configured.
+func (w *Server) listen() (net.Listener, error) {
+	tlsConfig, err := w.config.serverTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := w.hostPort
+	if addr == "" {
+		addr = ":http"
+	}
+	if tlsConfig == nil {
+		return net.Listen("tcp", addr)
+	}
+	return tls.Listen("tcp", addr, tlsConfig)
 }
 
-// Stop stops the server and blocks until it has finished.
+// Stop stops the server and its background samplers, and blocks until they have finished.
 func (w *Server) Stop() {
+	w.deadEvents.stop()
 	w.server.Close()
 	w.wg.Wait()
+	w.sampler.stop()
+}
+
+func (c *context) uptime(rw web.ResponseWriter, r *web.Request) {
+	response := struct {
+		StartedAt int64 `json:"started_at"`
+		Uptime    int64 `json:"uptime"`
+	}{StartedAt: c.startedAt, Uptime: nowEpochSeconds() - c.startedAt}
+
+	render(rw, response, nil)
 }
 
 func (c *context) queues(rw web.ResponseWriter, r *web.Request) {
-	response, err := c.client.Queues()
+	response, err := c.readClient.Queues()
 	render(rw, response, err)
 }
 
+type oldestPending struct {
+	Queue string `json:"queue"`
+	Age   int64  `json:"age"`
+	*work.Job
+	Args json.RawMessage `json:"args"`
+}
+
+// oldestPending returns the job that has been waiting the longest across all queues, along with its queue and age in
+// seconds. It returns null when every queue is empty.
+func (c *context) oldestPending(rw web.ResponseWriter, r *web.Request) {
+	queues, err := c.readClient.Queues()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	var oldest *oldestPending
+	for _, q := range queues {
+		if q.Count == 0 {
+			continue
+		}
+		jobs, err := c.readClient.PeekJobs(q.JobName, 1)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		if len(jobs) == 1 && (oldest == nil || jobs[0].EnqueuedAt < oldest.EnqueuedAt) {
+			oldest = &oldestPending{Queue: q.JobName, Job: jobs[0]}
+		}
+	}
+
+	if oldest != nil {
+		oldest.Age = nowEpochSeconds() - oldest.EnqueuedAt
+		if oldest.Args, err = oldest.ArgsJSON(); err != nil {
+			renderError(rw, err)
+			return
+		}
+	}
+
+	render(rw, oldest, nil)
+}
+
 func (c *context) workerPools(rw web.ResponseWriter, r *web.Request) {
-	response, err := c.client.WorkerPoolHeartbeats()
+	response, err := c.readClient.WorkerPoolHeartbeats()
 	render(rw, response, err)
 }
 
+func (c *context) knownJobs(rw web.ResponseWriter, r *web.Request) {
+	response, err := c.readClient.KnownJobs()
+	render(rw, response, err)
+}
+
+// busyWorkers lists the observations of the workers that are running a job. With the page param, and optionally
+// page_size, it returns a page of them ordered by worker ID, along with the count, like the job lists. Without it, it
+// returns all of them as a bare array, which is what the bundled UI reads.
 func (c *context) busyWorkers(rw web.ResponseWriter, r *web.Request) {
-	observations, err := c.client.WorkerObservations()
+	paginate := r.URL.Query().Get("page") != ""
+	page, pageSize, err := parsePage(rw, r, c.config.maxPageSize)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	observations, err := c.readClient.WorkerObservations()
 	if err != nil {
 		renderError(rw, err)
 		return
@@ -159,38 +500,245 @@ func (c *context) busyWorkers(rw web.ResponseWriter, r *web.Request) {
 		}
 	}
 
-	render(rw, busyObservations, err)
+	if !paginate {
+		render(rw, busyObservations, err)
+		return
+	}
+
+	sort.Slice(busyObservations, func(i, j int) bool {
+		return busyObservations[i].WorkerID < busyObservations[j].WorkerID
+	})
+	start, end := pageBounds(len(busyObservations), page, pageSize)
+
+	response := struct {
+		Count        int64                     `json:"count"`
+		Observations []*work.WorkerObservation `json:"observations"`
+	}{Count: int64(len(busyObservations)), Observations: busyObservations[start:end]}
+
+	render(rw, response, nil)
+}
+
+// The job types below wrap the client's jobs for responses. Their Args field shadows the job's own, so that args that
+// aren't a JSON object are rendered as they are instead of as null.
+
+type retryJob struct {
+	*work.RetryJob
+	Args json.RawMessage `json:"args"`
+}
+
+type scheduledJob struct {
+	*work.ScheduledJob
+	Args json.RawMessage `json:"args"`
+}
+
+// reapBusyWorkers clears the observations of workers whose pool has stopped sending heartbeats, so they no longer
+// show up as busy.
+func (c *context) reapBusyWorkers(rw web.ResponseWriter, r *web.Request) {
+	reaped, err := c.client.ReapWorkerObservations()
+
+	response := struct {
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}{Status: "ok", Count: reaped}
+
+	render(rw, response, err)
+}
+
+// requeueInFlight moves the jobs a crashed worker pool had in progress back onto their queues. It responds with a 409,
+// requeueing nothing, unless the pool has stopped sending heartbeats long enough to be considered dead.
+func (c *context) requeueInFlight(rw web.ResponseWriter, r *web.Request) {
+	requeued, err := c.client.RequeueInProgressJobs(r.PathParams["pool_id"])
+	if err == work.ErrPoolNotStale {
+		renderErrorCode(rw, http.StatusConflict, err)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}{Status: "ok", Count: requeued}
+
+	render(rw, response, err)
 }
 
 func (c *context) retryJobs(rw web.ResponseWriter, r *web.Request) {
-	page, err := parsePage(r)
+	page, pageSize, err := parsePage(rw, r, c.config.maxPageSize)
 	if err != nil {
 		renderError(rw, err)
 		return
 	}
 
-	jobs, count, err := c.client.RetryJobs(page)
+	truncate, err := parseTruncateArgs(r)
 	if err != nil {
 		renderError(rw, err)
 		return
 	}
 
-	response := struct {
-		Count int64            `json:"count"`
-		Jobs  []*work.RetryJob `json:"jobs"`
-	}{Count: count, Jobs: jobs}
+	jobs, count, err := c.readClient.RetryJobsPage(page, pageSize)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
 
-	render(rw, response, err)
+	response := &jobList{Count: count}
+
+	for _, j := range jobs {
+		args, err := listArgs(j.Job, truncate)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		response.Jobs = append(response.Jobs, &retryJob{RetryJob: j, Args: args})
+	}
+
+	renderJobs(rw, r, response, err)
+}
+
+// exhaustedRetryJobs lists the retry jobs that have failed at least max_fails times. A job with as many fails as its
+// max should have been moved to the dead set instead of being retried, so any listed are stuck.
+func (c *context) exhaustedRetryJobs(rw web.ResponseWriter, r *web.Request) {
+	maxFails, err := parseMaxFails(r)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	if maxFails == 0 {
+		renderError(rw, badRequestError("max_fails is required"))
+		return
+	}
+
+	truncate, err := parseTruncateArgs(r)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	jobs, err := c.readClient.AllRetryJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := &jobList{Jobs: []listedJob{}}
+
+	for _, j := range jobs {
+		if j.Fails < maxFails {
+			continue
+		}
+		args, err := listArgs(j.Job, truncate)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		response.Jobs = append(response.Jobs, &retryJob{RetryJob: j, Args: args})
+	}
+	response.Count = int64(len(response.Jobs))
+
+	renderJobs(rw, r, response, nil)
+}
+
+// defaultMostRetriedLimit is how many jobs /retry_jobs/most_retried returns without a limit param.
+const defaultMostRetriedLimit = 10
+
+// mostRetriedJobs ranks the retry jobs by how many times they've failed, most first, to find chronically flaky jobs.
+// The limit param caps how many are returned; it defaults to 10 and can be at most the max page size. Jobs with as
+// many fails keep their order in the retry set.
+func (c *context) mostRetriedJobs(rw web.ResponseWriter, r *web.Request) {
+	limit := uint64(defaultMostRetriedLimit)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.ParseUint(limitStr, 10, 0)
+		if err != nil {
+			renderError(rw, badParam("limit", err))
+			return
+		}
+		if limit == 0 || limit > uint64(c.config.maxPageSize) {
+			renderError(rw, badRequestError(fmt.Sprintf("limit must be between 1 and %d", c.config.maxPageSize)))
+			return
+		}
+	}
+
+	truncate, err := parseTruncateArgs(r)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	jobs, err := c.readClient.AllRetryJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].Fails > jobs[j].Fails
+	})
+	if uint64(len(jobs)) > limit {
+		jobs = jobs[:limit]
+	}
+
+	response := &jobList{Count: int64(len(jobs)), Jobs: make([]listedJob, 0, len(jobs))}
+
+	for _, j := range jobs {
+		args, err := listArgs(j.Job, truncate)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		response.Jobs = append(response.Jobs, &retryJob{RetryJob: j, Args: args})
+	}
+
+	renderJobs(rw, r, response, nil)
 }
 
 func (c *context) scheduledJobs(rw web.ResponseWriter, r *web.Request) {
-	page, err := parsePage(r)
+	page, pageSize, err := parsePage(rw, r, c.config.maxPageSize)
 	if err != nil {
 		renderError(rw, err)
 		return
 	}
 
-	jobs, count, err := c.client.ScheduledJobs(page)
+	truncate, err := parseTruncateArgs(r)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	jobs, count, err := c.readClient.ScheduledJobsPage(page, pageSize)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := &jobList{Count: count}
+
+	for _, j := range jobs {
+		args, err := listArgs(j.Job, truncate)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		response.Jobs = append(response.Jobs, &scheduledJob{ScheduledJob: j, Args: args})
+	}
+
+	renderJobs(rw, r, response, err)
+}
+
+type scheduledJobDelay struct {
+	*scheduledJob
+	SecondsUntilRun int64 `json:"seconds_until_run"`
+}
+
+// scheduledJobDelays lists the scheduled jobs soonest first, like scheduledJobs, with how many seconds are left until
+// each is due. Overdue jobs have a negative seconds_until_run.
+func (c *context) scheduledJobDelays(rw web.ResponseWriter, r *web.Request) {
+	page, pageSize, err := parsePage(rw, r, c.config.maxPageSize)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	jobs, count, err := c.readClient.ScheduledJobsPage(page, pageSize)
 	if err != nil {
 		renderError(rw, err)
 		return
@@ -198,65 +746,562 @@ func (c *context) scheduledJobs(rw web.ResponseWriter, r *web.Request) {
 
 	response := struct {
 		Count int64                `json:"count"`
-		Jobs  []*work.ScheduledJob `json:"jobs"`
-	}{Count: count, Jobs: jobs}
+		Jobs  []*scheduledJobDelay `json:"jobs"`
+	}{Count: count, Jobs: make([]*scheduledJobDelay, 0, len(jobs))}
 
-	render(rw, response, err)
+	now := nowEpochSeconds()
+	for _, j := range jobs {
+		args, err := j.ArgsJSON()
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		response.Jobs = append(response.Jobs, &scheduledJobDelay{
+			scheduledJob:    &scheduledJob{ScheduledJob: j, Args: args},
+			SecondsUntilRun: j.RunAt - now,
+		})
+	}
+
+	render(rw, response, nil)
+}
+
+// unreviewedStatus is the annotation status of dead jobs that nobody has annotated yet.
+const unreviewedStatus = "unreviewed"
+
+type deadJob struct {
+	*work.DeadJob
+	Args       json.RawMessage         `json:"args"`
+	Acked      bool                    `json:"acked"`
+	Annotation *work.DeadJobAnnotation `json:"annotation,omitempty"`
+	ETag       string                  `json:"etag"`
 }
 
 func (c *context) deadJobs(rw web.ResponseWriter, r *web.Request) {
-	page, err := parsePage(r)
+	page, pageSize, err := parsePage(rw, r, c.config.maxPageSize)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	truncate, err := parseTruncateArgs(r)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	filter, err := parseDeadJobFilter(r.Form)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	var jobs []*work.DeadJob
+	var annotations []*work.DeadJobAnnotation
+	var acked []bool
+	var count int64
+
+	if !filter.empty() {
+		jobs, annotations, acked, err = c.filteredDeadJobs(c.readClient, filter)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		count = int64(len(jobs))
+		start, end := pageBounds(len(jobs), page, pageSize)
+		jobs, annotations, acked = jobs[start:end], annotations[start:end], acked[start:end]
+	} else {
+		jobs, count, err = c.readClient.DeadJobsPage(page, pageSize)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		annotations, err = c.readClient.DeadJobAnnotations(jobs)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		acked, err = c.readClient.DeadJobsAcked(jobs)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+	}
+
+	response := &jobList{Count: count, Jobs: make([]listedJob, 0, len(jobs))}
+
+	for i, j := range jobs {
+		args, err := listArgs(j.Job, truncate)
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		response.Jobs = append(response.Jobs, &deadJob{
+			DeadJob:    j,
+			Args:       args,
+			Acked:      acked[i],
+			Annotation: annotations[i],
+			ETag:       deadJobETag(j, acked[i], annotations[i]),
+		})
+	}
+
+	renderJobs(rw, r, response, err)
+}
+
+// deadJobFilter selects dead jobs by the query params shared by the dead job endpoints. Zero values match any job.
+type deadJobFilter struct {
+	// name matches the job name exactly.
+	name string
+	// err matches jobs whose last error contains it.
+	err string
+	// status matches the status of the job's annotation. Jobs without an annotation match unreviewedStatus.
+	status string
+	// acked matches whether the job has been acknowledged.
+	acked *bool
+}
+
+// parseDeadJobFilter parses the name, error, status, and acked params, usually from the request's parsed form.
+func parseDeadJobFilter(params url.Values) (*deadJobFilter, error) {
+	f := &deadJobFilter{
+		name:   params.Get("name"),
+		err:    params.Get("error"),
+		status: params.Get("status"),
+	}
+
+	if ackedStr := params.Get("acked"); ackedStr != "" {
+		acked, err := strconv.ParseBool(ackedStr)
+		if err != nil {
+			return nil, badParam("acked", err)
+		}
+		f.acked = &acked
+	}
+
+	return f, nil
+}
+
+func (f *deadJobFilter) empty() bool {
+	return *f == deadJobFilter{}
+}
+
+func (f *deadJobFilter) matches(j *work.DeadJob, annotation *work.DeadJobAnnotation, acked bool) bool {
+	if f.name != "" && j.Name != f.name {
+		return false
+	}
+	if f.err != "" && !strings.Contains(j.LastErr, f.err) {
+		return false
+	}
+	if f.status != "" {
+		status := unreviewedStatus
+		if annotation != nil {
+			status = annotation.Status
+		}
+		if status != f.status {
+			return false
+		}
+	}
+	if f.acked != nil && acked != *f.acked {
+		return false
+	}
+	return true
+}
+
+// filteredDeadJobs uses client to get all dead jobs matching filter, along with their annotations and whether they've
+// been acknowledged.
+func (c *context) filteredDeadJobs(client *work.Client, filter *deadJobFilter) ([]*work.DeadJob, []*work.DeadJobAnnotation, []bool, error) {
+	all, err := client.AllDeadJobs()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	allAnnotations, err := client.DeadJobAnnotations(all)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	allAcked, err := client.DeadJobsAcked(all)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var jobs []*work.DeadJob
+	var annotations []*work.DeadJobAnnotation
+	var acked []bool
+	for i, j := range all {
+		if !filter.matches(j, allAnnotations[i], allAcked[i]) {
+			continue
+		}
+		jobs = append(jobs, j)
+		annotations = append(annotations, allAnnotations[i])
+		acked = append(acked, allAcked[i])
+	}
+
+	return jobs, annotations, acked, nil
+}
+
+// ackAllDeadJobs acknowledges every dead job matching the same filters as /dead_jobs, and returns how many matched.
+func (c *context) ackAllDeadJobs(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	filter, err := parseDeadJobFilter(r.Form)
 	if err != nil {
 		renderError(rw, err)
 		return
 	}
 
-	jobs, count, err := c.client.DeadJobs(page)
+	jobs, _, _, err := c.filteredDeadJobs(c.client, filter)
 	if err != nil {
 		renderError(rw, err)
 		return
 	}
 
+	if err := c.client.AckDeadJobs(jobs); err != nil {
+		renderError(rw, err)
+		return
+	}
+
 	response := struct {
-		Count int64           `json:"count"`
-		Jobs  []*work.DeadJob `json:"jobs"`
-	}{Count: count, Jobs: jobs}
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}{Status: "ok", Count: int64(len(jobs))}
 
-	render(rw, response, err)
+	render(rw, response, nil)
+}
+
+// annotateDeadJobs annotates every dead job matching the filter in the query string with the status and note in the
+// request body, and returns how many were annotated.
+func (c *context) annotateDeadJobs(rw web.ResponseWriter, r *web.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	// The filter and the annotation both have a status, so the filter only comes from the query string.
+	filter, err := parseDeadJobFilter(r.URL.Query())
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	status := r.PostForm.Get("status")
+	if status == "" {
+		renderError(rw, badRequestError("status is required"))
+		return
+	}
+
+	jobs, _, _, err := c.filteredDeadJobs(c.client, filter)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	err = c.client.AnnotateDeadJobs(jobs, &work.DeadJobAnnotation{
+		Status: status,
+		Note:   r.PostForm.Get("note"),
+	})
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}{Status: "ok", Count: int64(len(jobs))}
+
+	render(rw, response, nil)
+}
+
+func (c *context) annotateDeadJob(rw web.ResponseWriter, r *web.Request) {
+	diedAt, err := strconv.ParseInt(r.PathParams["died_at"], 10, 64)
+	if err != nil {
+		renderError(rw, badParam("died_at", err))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		renderError(rw, badRequestError(err.Error()))
+		return
+	}
+
+	status := r.Form.Get("status")
+	if status == "" {
+		renderError(rw, badRequestError("status is required"))
+		return
+	}
+
+	err = c.client.AnnotateDeadJob(diedAt, r.PathParams["job_id"], &work.DeadJobAnnotation{
+		Status: status,
+		Note:   r.Form.Get("note"),
+	})
+
+	render(rw, &statusResponse{Status: "ok"}, err)
+}
+
+func (c *context) ackDeadJob(rw web.ResponseWriter, r *web.Request) {
+	diedAt, err := strconv.ParseInt(r.PathParams["died_at"], 10, 64)
+	if err != nil {
+		renderError(rw, badParam("died_at", err))
+		return
+	}
+
+	err = c.client.AckDeadJob(diedAt, r.PathParams["job_id"])
+
+	render(rw, &statusResponse{Status: "ok"}, err)
 }
 
 func (c *context) deleteDeadJob(rw web.ResponseWriter, r *web.Request) {
 	diedAt, err := strconv.ParseInt(r.PathParams["died_at"], 10, 64)
 	if err != nil {
+		renderError(rw, badParam("died_at", err))
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ok, err := c.deadJobMatches(ifMatch, diedAt, r.PathParams["job_id"]); err != nil {
 		renderError(rw, err)
 		return
+	} else if !ok {
+		renderConflict(rw)
+		return
 	}
 
 	err = c.client.DeleteDeadJob(diedAt, r.PathParams["job_id"])
+	if err == work.ErrNotDeleted && ifMatch != "" {
+		renderConflict(rw)
+		return
+	}
+
+	render(rw, &statusResponse{Status: "ok"}, err)
+}
+
+// deadJobMatches checks an If-Match header against the current state of the dead job a request acts on. Clients can
+// set If-Match to the etag /dead_jobs last listed the job with, to get a 409 rather than acting on the job if it has
+// since been acknowledged, annotated, retried, or deleted, or to "*" to only check that it's still dead. An empty
+// If-Match always matches.
+func (c *context) deadJobMatches(ifMatch string, diedAt int64, jobID string) (bool, error) {
+	ifMatch = strings.Trim(ifMatch, `"`)
+	if ifMatch == "" {
+		return true, nil
+	}
+
+	job, err := c.client.FindDeadJob(diedAt, jobID)
+	if err != nil || job == nil {
+		return false, err
+	}
+	if ifMatch == "*" {
+		return true, nil
+	}
 
-	render(rw, map[string]string{"status": "ok"}, err)
+	jobs := []*work.DeadJob{job}
+	acked, err := c.client.DeadJobsAcked(jobs)
+	if err != nil {
+		return false, err
+	}
+	annotations, err := c.client.DeadJobAnnotations(jobs)
+	if err != nil {
+		return false, err
+	}
+	return ifMatch == deadJobETag(job, acked[0], annotations[0]), nil
 }
 
+// deadJobETag tags the state of a dead job: the job itself, and whether and how it's been acknowledged and annotated.
+func deadJobETag(job *work.DeadJob, acked bool, annotation *work.DeadJobAnnotation) string {
+	b, _ := json.Marshal(struct {
+		Job        *work.DeadJob
+		Acked      bool
+		Annotation *work.DeadJobAnnotation
+	}{job, acked, annotation})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:12])
+}
+
+func renderConflict(rw web.ResponseWriter) {
+	renderErrorCode(rw, http.StatusConflict, errors.New("job has changed since it was last read"))
+}
+
+// retryDeadJob requeues a dead job, and reports the queue it went to and roughly where it is in that queue.
 func (c *context) retryDeadJob(rw web.ResponseWriter, r *web.Request) {
 	diedAt, err := strconv.ParseInt(r.PathParams["died_at"], 10, 64)
+	if err != nil {
+		renderError(rw, badParam("died_at", err))
+		return
+	}
+
+	maxFails, err := parseMaxFails(r)
 	if err != nil {
 		renderError(rw, err)
 		return
 	}
 
-	err = c.client.RetryDeadJob(diedAt, r.PathParams["job_id"])
+	ifMatch := r.Header.Get("If-Match")
+	if ok, err := c.deadJobMatches(ifMatch, diedAt, r.PathParams["job_id"]); err != nil {
+		renderError(rw, err)
+		return
+	} else if !ok {
+		renderConflict(rw)
+		return
+	}
+
+	job, err := c.client.FindDeadJob(diedAt, r.PathParams["job_id"])
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+	if maxFails > 0 && job != nil && job.Fails >= maxFails {
+		render(rw, &statusResponse{Status: "skipped"}, nil)
+		return
+	}
 
-	render(rw, map[string]string{"status": "ok"}, err)
+	if err := c.client.RetryDeadJob(diedAt, r.PathParams["job_id"]); err == work.ErrNotRetried && ifMatch != "" {
+		renderConflict(rw)
+		return
+	} else if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := struct {
+		Status   string `json:"status"`
+		Queue    string `json:"queue,omitempty"`
+		Position int64  `json:"position,omitempty"`
+	}{Status: "ok"}
+
+	// The job is pushed onto the back of its queue, so its position is about the queue's depth. Other jobs may have
+	// been enqueued or processed since.
+	if job != nil {
+		queues, err := c.client.Queues()
+		if err != nil {
+			renderError(rw, err)
+			return
+		}
+		for _, q := range queues {
+			if q.JobName == job.Name {
+				response.Queue, response.Position = q.JobName, q.Count
+			}
+		}
+	}
+
+	render(rw, response, nil)
 }
 
 func (c *context) deleteAllDeadJobs(rw web.ResponseWriter, r *web.Request) {
 	err := c.client.DeleteAllDeadJobs()
-	render(rw, map[string]string{"status": "ok"}, err)
+	render(rw, &statusResponse{Status: "ok"}, err)
 }
 
+// retryAllDeadJobs requeues dead jobs, or with max_fails only those that have failed fewer times. Without max_fails the
+// jobs are always requeued in the order they died. With it, they're retried concurrently unless fifo=true, which
+// requeues them one at a time in the order they died.
 func (c *context) retryAllDeadJobs(rw web.ResponseWriter, r *web.Request) {
-	err := c.client.RetryAllDeadJobs()
-	render(rw, map[string]string{"status": "ok"}, err)
+	maxFails, err := parseMaxFails(r)
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	if maxFails == 0 {
+		err := c.client.RetryAllDeadJobs()
+		render(rw, &statusResponse{Status: "ok"}, err)
+		return
+	}
+
+	jobs, err := c.client.AllDeadJobs()
+	if err != nil {
+		renderError(rw, err)
+		return
+	}
+
+	response := struct {
+		Status  string `json:"status"`
+		Retried int64  `json:"retried"`
+		Skipped int64  `json:"skipped"`
+	}{Status: "ok"}
+
+	var retryable []*work.DeadJob
+	for _, j := range jobs {
+		if j.Fails >= maxFails {
+			response.Skipped++
+			continue
+		}
+		retryable = append(retryable, j)
+	}
+
+	concurrency := c.config.retryConcurrency
+	if r.URL.Query().Get("fifo") == "true" {
+		sort.SliceStable(retryable, func(i, j int) bool {
+			return retryable[i].DiedAt < retryable[j].DiedAt
+		})
+		concurrency = 1
+	}
+
+	response.Retried, err = c.retryDeadJobs(retryable, concurrency)
+
+	render(rw, response, err)
+}
+
+// retryDeadJobs retries each of jobs individually, using up to concurrency goroutines within the server's fanOut. With
+// a concurrency of 1 the jobs are requeued in order. It returns the number of jobs actually retried; jobs that are no
+// longer dead aren't counted.
+func (c *context) retryDeadJobs(jobs []*work.DeadJob, concurrency int) (int64, error) {
+	var retried int64
+	err := c.fanOut.forEach(len(jobs), concurrency, func(i int) error {
+		err := c.client.RetryDeadJob(jobs[i].DiedAt, jobs[i].ID)
+		if err == work.ErrNotRetried {
+			// Somebody else got to it first
+			return nil
+		} else if err != nil {
+			return err
+		}
+		atomic.AddInt64(&retried, 1)
+		return nil
+	})
+	return retried, err
+}
+
+// retryBatchSize is how many retry jobs are moved per redis round trip when retrying them all at once.
+const retryBatchSize = 1000
+
+// retryAllRetryJobsNow requeues every job in the retry queue without waiting out its backoff. With dry_run=true it
+// only reports how many jobs would be requeued.
+func (c *context) retryAllRetryJobsNow(rw web.ResponseWriter, r *web.Request) {
+	response := struct {
+		Count  int64 `json:"count"`
+		DryRun bool  `json:"dry_run"`
+	}{DryRun: r.URL.Query().Get("dry_run") == "true"}
+
+	var err error
+	if response.DryRun {
+		response.Count, err = c.client.RetryJobsCount()
+	} else {
+		response.Count, err = c.client.RetryAllRetryJobs(retryBatchSize)
+	}
+
+	render(rw, response, err)
+}
+
+// parseMaxFails parses the optional max_fails query param, which must be at least 1, eg to limit retries to jobs that
+// have failed fewer than max_fails times. It returns 0 when the param isn't given.
+func parseMaxFails(r *web.Request) (int64, error) {
+	maxFailsStr := r.URL.Query().Get("max_fails")
+	if maxFailsStr == "" {
+		return 0, nil
+	}
+
+	maxFails, err := strconv.ParseInt(maxFailsStr, 10, 64)
+	if err != nil {
+		return 0, badParam("max_fails", err)
+	}
+	if maxFails < 1 {
+		return 0, badRequestError("max_fails must be at least 1")
+	}
+	return maxFails, nil
+}
+
+// statusResponse is the response of the endpoints that only report whether they did what was asked.
+type statusResponse struct {
+	Status string `json:"status"`
 }
 
 func render(rw web.ResponseWriter, jsonable interface{}, err error) {
@@ -270,18 +1315,97 @@ func render(rw web.ResponseWriter, jsonable interface{}, err error) {
 		renderError(rw, err)
 		return
 	}
+	if namespace := rw.Header().Get(namespaceHeader); namespace != "" && hasMeta(jsonable) {
+		jsonData = withMeta(jsonData, namespace)
+	}
 	rw.Write(jsonData)
 }
 
+// renderError responds with err. Errors caused by the client's request, which handlers return as badRequestErrors
+// (see badParam), are a 400; an exhausted redis pool is a 503; anything else is a backend failure and a 500.
 func renderError(rw http.ResponseWriter, err error) {
-	rw.WriteHeader(500)
-	fmt.Fprintf(rw, `{"error": "%s"}`, err.Error())
+	if err == redis.ErrPoolExhausted {
+		renderUnavailable(rw, time.Second)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	if _, ok := err.(badRequestError); ok {
+		status = http.StatusBadRequest
+	}
+	renderErrorCode(rw, status, err)
 }
 
-func parsePage(r *web.Request) (uint, error) {
+// renderErrorCode responds with err and the given status, for handlers that know better than renderError which status
+// an error deserves.
+func renderErrorCode(rw http.ResponseWriter, code int, err error) {
+	writeError(rw, code, err.Error())
+}
+
+// writeError responds with status and a JSON body of the error message. The message is marshaled rather than
+// formatted in, so that quotes, backslashes and newlines in it don't break the JSON. The content type is set here too,
+// since the response writer needn't have been through the middleware that sets it.
+func writeError(rw http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{message})
+
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	rw.Write(body)
+}
+
+// badRequestError is an error caused by the request's params. renderError responds to it with a 400.
+type badRequestError string
+
+func (e badRequestError) Error() string {
+	return string(e)
+}
+
+// badParam returns the error for the param named name that failed to parse, eg with strconv, as a badRequestError.
+// Parse errors of data read from redis aren't the client's fault, so they're left as they are.
+func badParam(name string, err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok {
+		if numErr.Err == strconv.ErrRange {
+			return badRequestError(fmt.Sprintf("%s %q is out of range", name, numErr.Num))
+		}
+		return badRequestError(fmt.Sprintf("invalid %s %q", name, numErr.Num))
+	}
+	return badRequestError(fmt.Sprintf("invalid %s: %v", name, err))
+}
+
+// jobsPerPage matches the page size used by work.Client for the retry, scheduled, and dead lists. It's the default for
+// the page_size param.
+const jobsPerPage = 20
+
+// pageBounds returns the slice bounds of the 1-based page within a list of n items.
+func pageBounds(n int, page, pageSize uint) (int, int) {
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = jobsPerPage
+	}
+	start := int(page-1) * int(pageSize)
+	if start > n {
+		start = n
+	}
+	end := start + int(pageSize)
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+// pageSizeClampedHeader is set on list responses whose page_size param was clamped to the max page size, to that size.
+const pageSizeClampedHeader = "X-Page-Size-Clamped"
+
+// parsePage returns the page and page_size params. A page_size above maxPageSize is clamped to it, and the response
+// gets a pageSizeClampedHeader so that clients find out about the limit.
+func parsePage(rw web.ResponseWriter, r *web.Request, maxPageSize uint) (uint, uint, error) {
 	err := r.ParseForm()
 	if err != nil {
-		return 0, err
+		return 0, 0, badRequestError(err.Error())
 	}
 
 	pageStr := r.Form.Get("page")
@@ -290,5 +1414,24 @@ func parsePage(r *web.Request) (uint, error) {
 	}
 
 	page, err := strconv.ParseUint(pageStr, 10, 0)
-	return uint(page), err
+	if err != nil {
+		return 0, 0, badParam("page", err)
+	}
+
+	pageSize := uint64(jobsPerPage)
+	if sizeStr := r.Form.Get("page_size"); sizeStr != "" {
+		pageSize, err = strconv.ParseUint(sizeStr, 10, 0)
+		if err != nil {
+			return 0, 0, badParam("page_size", err)
+		}
+		if pageSize == 0 {
+			return 0, 0, badRequestError("page_size must be at least 1")
+		}
+		if pageSize > uint64(maxPageSize) {
+			pageSize = uint64(maxPageSize)
+			rw.Header().Set(pageSizeClampedHeader, strconv.FormatUint(pageSize, 10))
+		}
+	}
+
+	return uint(page), uint(pageSize), nil
 }
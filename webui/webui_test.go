@@ -1,10 +1,14 @@
 package webui
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -19,13 +23,107 @@ func TestWebUIStartStop(t *testing.T) {
 	ns := "work"
 	cleanKeyspace(ns, pool)
 
-	s := NewServer(ns, pool, ":6666")
-	s.Start()
+	s := NewServer(ns, pool, ":6666", "", "")
+	assert.NoError(t, s.Start())
 	s.Stop()
 }
 
+func TestWebUIStartPortInUse(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer l.Close()
+
+	s := NewServer(ns, pool, l.Addr().String(), "", "")
+	assert.Error(t, s.Start())
+	s.Stop()
+
+	// A certificate that can't be loaded fails it too
+	s = NewServer(ns, pool, "127.0.0.1:0", "", "", WithTLS("missing.pem", "missing.pem"))
+	assert.Error(t, s.Start())
+	s.Stop()
+}
+
+func TestWebUIHandler(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/queues")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 200, res.StatusCode)
+
+	// Mounted in an existing mux under a path prefix
+	s = NewServerWithOptions(ns, pool, ":6666", WithPathPrefix("/admin/work"))
+	mux := http.NewServeMux()
+	mux.Handle("/admin/work/", s.Handler())
+	ts = httptest.NewServer(mux)
+	defer ts.Close()
+
+	res, err = http.Get(ts.URL + "/admin/work/queues")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 200, res.StatusCode)
+
+	// Or with the prefix stripped by the mux's own handler
+	s = NewServer(ns, pool, ":6666", "", "")
+	mux = http.NewServeMux()
+	mux.Handle("/admin/work/", http.StripPrefix("/admin/work", s.Handler()))
+	ts = httptest.NewServer(mux)
+	defer ts.Close()
+
+	res, err = http.Get(ts.URL + "/admin/work/queues")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 200, res.StatusCode)
+}
+
+func TestWebUIHandlerSamplers(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithSamplerInterval(10*time.Millisecond))
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	defer s.Stop()
+
+	// The samplers run without Start, so the error rate is soon taken between two samples
+	var to int64
+	for deadline := time.Now().Add(2 * time.Second); to == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		res, err := http.Get(ts.URL + "/slo/error_rate")
+		if !assert.NoError(t, err) {
+			return
+		}
+		var body struct {
+			To int64 `json:"to"`
+		}
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		res.Body.Close()
+		to = body.To
+	}
+	assert.NotEqual(t, int64(0), to)
+}
+
 type TestContext struct{}
 
+func TestWebUIEmptyNamespace(t *testing.T) {
+	pool := newTestPool(":6379")
+	assert.Panics(t, func() {
+		NewServer("", pool, ":6666", "", "")
+	})
+}
+
 func TestWebUIQueues(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "work"
@@ -62,7 +160,7 @@ func TestWebUIQueues(t *testing.T) {
 	enqueuer.Enqueue("foo", nil)
 	enqueuer.Enqueue("zaz", nil)
 
-	s := NewServer(ns, pool, ":6666")
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/queues", nil)
@@ -82,6 +180,45 @@ func TestWebUIQueues(t *testing.T) {
 	assert.EqualValues(t, 0, foomap["latency"])
 }
 
+func TestWebUIOldestPending(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/oldest_pending", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "null", recorder.Body.String())
+
+	insertQueuedJob(ns, pool, "wat", 1425263300)
+	insertQueuedJob(ns, pool, "wat", 1425263400)
+	insertQueuedJob(ns, pool, "foo", 1425263200)
+	insertQueuedJob(ns, pool, "foo", 1425263350)
+	insertQueuedJob(ns, pool, "bar", 1425263250)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/oldest_pending", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		Queue string `json:"queue"`
+		Age   int64  `json:"age"`
+		ID    string `json:"id"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", res.Queue)
+	assert.EqualValues(t, 209, res.Age)
+	assert.Equal(t, "foo-1425263200", res.ID)
+}
+
 func TestWebUIWorkerPools(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "work"
@@ -101,7 +238,7 @@ func TestWebUIWorkerPools(t *testing.T) {
 
 	time.Sleep(20 * time.Millisecond)
 
-	s := NewServer(ns, pool, ":6666")
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/worker_pools", nil)
@@ -120,6 +257,47 @@ func TestWebUIWorkerPools(t *testing.T) {
 	// NOTE: WorkerPoolStatus is tested elsewhere.
 }
 
+func TestWebUIKnownJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	wp := work.NewWorkerPool(TestContext{}, 10, ns, pool)
+	wp.Job("wat", func(job *work.Job) error { return nil })
+	wp.Start()
+	defer wp.Stop()
+
+	wp2 := work.NewWorkerPool(TestContext{}, 4, ns, pool)
+	wp2.Job("wat", func(job *work.Job) error { return nil })
+	wp2.Job("foo", func(job *work.Job) error { return nil })
+	wp2.Start()
+	defer wp2.Stop()
+
+	time.Sleep(20 * time.Millisecond)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res []struct {
+		JobName        string `json:"job_name"`
+		MaxConcurrency uint   `json:"max_concurrency"`
+		InProgress     int64  `json:"in_progress"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(res)) {
+		assert.Equal(t, "foo", res[0].JobName)
+		assert.EqualValues(t, 4, res[0].MaxConcurrency)
+		assert.Equal(t, "wat", res[1].JobName)
+		assert.EqualValues(t, 14, res[1].MaxConcurrency)
+		assert.EqualValues(t, 0, res[1].InProgress)
+	}
+}
+
 func TestWebUIBusyWorkers(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "work"
@@ -145,7 +323,7 @@ func TestWebUIBusyWorkers(t *testing.T) {
 
 	time.Sleep(10 * time.Millisecond)
 
-	s := NewServer(ns, pool, ":6666")
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/busy_workers", nil)
@@ -182,6 +360,101 @@ func TestWebUIBusyWorkers(t *testing.T) {
 	}
 }
 
+func TestWebUIBusyWorkersPage(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":worker_pools", "p1")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:p1", "heartbeat_at", time.Now().Unix(), "worker_ids", "w3,w1,w4,w2")
+	assert.NoError(t, err)
+	for _, id := range []string{"w1", "w2", "w3"} {
+		_, err = conn.Do("HMSET", ns+":worker:"+id, "job_name", "wat", "job_id", "job-"+id, "started_at", time.Now().Unix())
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	get := func(path string) (int64, []string) {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res struct {
+			Count        int64                     `json:"count"`
+			Observations []*work.WorkerObservation `json:"observations"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		var ids []string
+		for _, ob := range res.Observations {
+			ids = append(ids, ob.WorkerID)
+		}
+		return res.Count, ids
+	}
+
+	// w4 is idle, so it isn't counted
+	count, ids := get("/busy_workers?page=1&page_size=2")
+	assert.EqualValues(t, 3, count)
+	assert.Equal(t, []string{"w1", "w2"}, ids)
+
+	count, ids = get("/busy_workers?page=2&page_size=2")
+	assert.EqualValues(t, 3, count)
+	assert.Equal(t, []string{"w3"}, ids)
+
+	count, ids = get("/busy_workers?page=3&page_size=2")
+	assert.EqualValues(t, 3, count)
+	assert.Empty(t, ids)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/busy_workers?page=nope", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+}
+
+func TestWebUIReapBusyWorkers(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":worker_pools", "crashed")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:crashed", "heartbeat_at", time.Now().Add(-time.Hour).Unix(), "worker_ids", "w1")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker:w1", "job_name", "wat", "job_id", "abc", "started_at", time.Now().Add(-time.Hour).Unix())
+	assert.NoError(t, err)
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/busy_workers/reap", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Status)
+	assert.EqualValues(t, 1, res.Count)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/busy_workers", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var busy []interface{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &busy)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(busy))
+}
+
 func TestWebUIRetryJobs(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "work"
@@ -199,7 +472,7 @@ func TestWebUIRetryJobs(t *testing.T) {
 	wp.Drain()
 	wp.Stop()
 
-	s := NewServer(ns, pool, ":6666")
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/retry_jobs", nil)
@@ -225,6 +498,62 @@ func TestWebUIRetryJobs(t *testing.T) {
 	}
 }
 
+func TestWebUIExhaustedRetryJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertRetryJobWithFails(ns, pool, "wat", 100, 10, 1)
+	insertRetryJobWithFails(ns, pool, "wat", 101, 11, 4)
+	insertRetryJobWithFails(ns, pool, "foo", 102, 12, 5)
+	insertRetryJobWithFails(ns, pool, "foo", 103, 13, 25)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	get := func(query string) (int, []string) {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/retry_jobs/exhausted?"+query, nil)
+		s.router.ServeHTTP(recorder, request)
+
+		var res struct {
+			Count int64 `json:"count"`
+			Jobs  []struct {
+				ID    string `json:"id"`
+				Fails int64  `json:"fails"`
+			} `json:"jobs"`
+		}
+		ids := []string{}
+		if recorder.Code == 200 {
+			err := json.Unmarshal(recorder.Body.Bytes(), &res)
+			assert.NoError(t, err)
+			assert.EqualValues(t, len(res.Jobs), res.Count)
+			for _, j := range res.Jobs {
+				ids = append(ids, j.ID)
+			}
+		}
+		return recorder.Code, ids
+	}
+
+	code, ids := get("max_fails=5")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{"foo-12", "foo-13"}, ids)
+
+	code, ids = get("max_fails=1")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{"wat-10", "wat-11", "foo-12", "foo-13"}, ids)
+
+	code, ids = get("max_fails=26")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{}, ids)
+
+	code, _ = get("")
+	assert.Equal(t, 400, code)
+	code, _ = get("max_fails=0")
+	assert.Equal(t, 400, code)
+	code, _ = get("max_fails=lots")
+	assert.Equal(t, 400, code)
+}
+
 func TestWebUIScheduledJobs(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "testwork"
@@ -234,7 +563,7 @@ func TestWebUIScheduledJobs(t *testing.T) {
 	_, err := enqueuer.EnqueueIn("watter", 1, nil)
 	assert.Nil(t, err)
 
-	s := NewServer(ns, pool, ":6666")
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/scheduled_jobs", nil)
@@ -258,6 +587,50 @@ func TestWebUIScheduledJobs(t *testing.T) {
 	}
 }
 
+func TestWebUIScheduledJobDelays(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	conn := pool.Get()
+	for id, runAt := range map[string]int64{"later": 1425263469, "overdue": 1425263379, "soon": 1425263419} {
+		rawJSON, _ := json.Marshal(&work.Job{Name: "wat", ID: id})
+		_, err := conn.Do("ZADD", ns+":scheduled", runAt, rawJSON)
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/scheduled_jobs/delays?page=1", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var res struct {
+		Count int64 `json:"count"`
+		Jobs  []struct {
+			ID              string `json:"id"`
+			RunAt           int64  `json:"run_at"`
+			SecondsUntilRun int64  `json:"seconds_until_run"`
+		} `json:"jobs"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 3, res.Count)
+	if assert.Equal(t, 3, len(res.Jobs)) {
+		assert.Equal(t, "overdue", res.Jobs[0].ID)
+		assert.EqualValues(t, -30, res.Jobs[0].SecondsUntilRun)
+		assert.Equal(t, "soon", res.Jobs[1].ID)
+		assert.EqualValues(t, 10, res.Jobs[1].SecondsUntilRun)
+		assert.Equal(t, "later", res.Jobs[2].ID)
+		assert.EqualValues(t, 60, res.Jobs[2].SecondsUntilRun)
+	}
+}
+
 func TestWebUIDeadJobs(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "testwork"
@@ -276,7 +649,7 @@ func TestWebUIDeadJobs(t *testing.T) {
 	wp.Drain()
 	wp.Stop()
 
-	s := NewServer(ns, pool, ":6666")
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/dead_jobs", nil)
@@ -346,6 +719,65 @@ func TestWebUIDeadJobs(t *testing.T) {
 	}
 }
 
+func TestWebUIPageSize(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	for i := int64(1); i <= 5; i++ {
+		insertDeadJob(ns, pool, "wat", i, 10+i)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "", WithMaxPageSize(3))
+
+	for _, path := range []string{"/dead_jobs", "/dead_jobs?name=wat", "/failing_jobs"} {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path+sep+"page=2&page_size=2", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code, path)
+
+		var res struct {
+			Count int64 `json:"count"`
+			Jobs  []struct {
+				FailedAt int64 `json:"failed_at"`
+			} `json:"jobs"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 5, res.Count, path)
+		assert.Equal(t, 2, len(res.Jobs), path)
+
+		assert.Equal(t, "", recorder.Header().Get(pageSizeClampedHeader), path)
+
+		// Too large a page size is clamped
+		recorder = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", path+sep+"page_size=4", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code, path)
+		assert.Equal(t, "3", recorder.Header().Get(pageSizeClampedHeader), path)
+		err = json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 5, res.Count, path)
+		assert.Equal(t, 3, len(res.Jobs), path)
+
+		recorder = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", path+sep+"page_size=0", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 400, recorder.Code, path)
+
+		// A query string that can't be parsed is the client's fault too
+		recorder = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", path+sep+"page=%zz", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 400, recorder.Code, path)
+	}
+}
+
 func TestWebUIDeadJobsDeleteRetryAll(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "testwork"
@@ -364,7 +796,7 @@ func TestWebUIDeadJobsDeleteRetryAll(t *testing.T) {
 	wp.Drain()
 	wp.Stop()
 
-	s := NewServer(ns, pool, ":6666")
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
 	request, _ := http.NewRequest("GET", "/dead_jobs", nil)
@@ -447,45 +879,1005 @@ func TestWebUIDeadJobsDeleteRetryAll(t *testing.T) {
 	assert.EqualValues(t, 0, res.Count)
 }
 
-func TestWebUIAssets(t *testing.T) {
+func TestWebUIDeadJobIfMatch(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	job := insertDeadJob(ns, pool, "wat", 1, 10)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	post := func(path, ifMatch string) int {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", path, nil)
+		request.Header.Set("If-Match", ifMatch)
+		s.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	etag := func() string {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/dead_jobs", nil)
+		s.router.ServeHTTP(recorder, request)
+		var res struct {
+			Jobs []struct {
+				ETag string `json:"etag"`
+			} `json:"jobs"`
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		if assert.Equal(t, 1, len(res.Jobs)) {
+			return res.Jobs[0].ETag
+		}
+		return ""
+	}
+
+	deletePath := fmt.Sprintf("/delete_dead_job/%d/%s", 10, job.ID)
+	retryPath := fmt.Sprintf("/retry_dead_job/%d/%s", 10, job.ID)
+
+	// One operator read the job before another acknowledged it, so their precondition is stale and the job isn't touched
+	seen := etag()
+	assert.NotEqual(t, "", seen)
+	assert.Equal(t, 200, post(fmt.Sprintf("/dead_job/%d/%s/ack", 10, job.ID), ""))
+	assert.NotEqual(t, seen, etag())
+	assert.Equal(t, 409, post(deletePath, `"`+seen+`"`))
+	assert.Equal(t, 409, post(retryPath, seen))
+	assert.Equal(t, 409, post(deletePath, `"10"`))
+	assert.EqualValues(t, 1, zsetSize(pool, ns+":dead"))
+
+	// With the current state it goes through, and then the other's retry and delete conflict
+	current := etag()
+	assert.Equal(t, 200, post(deletePath, `"`+current+`"`))
+	assert.Equal(t, 409, post(retryPath, current))
+	assert.Equal(t, 409, post(deletePath, "*"))
+	assert.EqualValues(t, 0, listSize(pool, ns+":jobs:wat"))
+
+	// Without a precondition, it's an error as before
+	assert.Equal(t, 500, post(deletePath, ""))
+}
+
+func TestWebUIAckDeadJob(t *testing.T) {
 	pool := newTestPool(":6379")
 	ns := "testwork"
-	s := NewServer(ns, pool, ":6666")
+	cleanKeyspace(ns, pool)
+
+	insertDeadJob(ns, pool, "wat", 12345, 12347)
+	j2 := insertDeadJob(ns, pool, "wat", 12345, 12348)
+
+	s := NewServer(ns, pool, ":6666", "", "")
 
 	recorder := httptest.NewRecorder()
-	request, _ := http.NewRequest("GET", "/", nil)
+	request, _ := http.NewRequest("POST", fmt.Sprintf("/dead_job/%d/%s/ack", 12348, j2.ID), strings.NewReader(""))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	s.router.ServeHTTP(recorder, request)
-	body := string(recorder.Body.Bytes())
-	assert.Regexp(t, "html", body)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		Count int64 `json:"count"`
+		Jobs  []struct {
+			ID    string `json:"id"`
+			Acked bool   `json:"acked"`
+		} `json:"jobs"`
+	}
 
 	recorder = httptest.NewRecorder()
-	request, _ = http.NewRequest("GET", "/work.js", nil)
+	request, _ = http.NewRequest("GET", "/dead_jobs", nil)
 	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, res.Count)
+	if assert.Equal(t, 2, len(res.Jobs)) {
+		assert.False(t, res.Jobs[0].Acked)
+		assert.Equal(t, j2.ID, res.Jobs[1].ID)
+		assert.True(t, res.Jobs[1].Acked)
+	}
 }
 
-func newTestPool(addr string) *redis.Pool {
-	return &redis.Pool{
-		MaxActive:   3,
-		MaxIdle:     3,
-		IdleTimeout: 240 * time.Second,
-		Dial: func() (redis.Conn, error) {
-			return redis.Dial("tcp", addr)
-		},
-		Wait: true,
+func TestWebUIDeadJobsAckedFilter(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	client := work.NewClient(ns, pool)
+	for i := int64(0); i < 25; i++ {
+		j := insertDeadJob(ns, pool, "wat", 12345, 13000+i)
+		if i%5 == 0 {
+			assert.NoError(t, client.AckDeadJob(13000+i, j.ID))
+		}
 	}
-}
 
-func cleanKeyspace(namespace string, pool *redis.Pool) {
-	conn := pool.Get()
-	defer conn.Close()
+	s := NewServer(ns, pool, ":6666", "", "")
 
-	keys, err := redis.Strings(conn.Do("KEYS", namespace+"*"))
-	if err != nil {
-		panic("could not get keys: " + err.Error())
+	type result struct {
+		Count int64 `json:"count"`
+		Jobs  []struct {
+			ID    string `json:"id"`
+			Acked bool   `json:"acked"`
+		} `json:"jobs"`
 	}
-	for _, k := range keys {
-		if _, err := conn.Do("DEL", k); err != nil {
-			panic("could not del: " + err.Error())
+
+	var res result
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/dead_jobs?acked=true", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, res.Count)
+	assert.Equal(t, 5, len(res.Jobs))
+	for _, j := range res.Jobs {
+		assert.True(t, j.Acked)
+	}
+
+	res = result{}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs?acked=false", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, res.Count)
+	assert.Equal(t, 20, len(res.Jobs))
+	for _, j := range res.Jobs {
+		assert.False(t, j.Acked)
+	}
+
+	// Combines with the annotation status filter
+	res = result{}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs?acked=false&status=unreviewed&page=2", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, res.Count)
+	assert.Equal(t, 0, len(res.Jobs))
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs?acked=maybe", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+}
+
+func TestWebUIAckAllDeadJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertDeadJobWithError(ns, pool, "wat", 0, 1, "dial tcp: i/o timeout")
+	insertDeadJobWithError(ns, pool, "wat", 0, 2, "dial tcp: i/o timeout")
+	insertDeadJobWithError(ns, pool, "wat", 0, 3, "ohno")
+	insertDeadJobWithError(ns, pool, "foo", 0, 4, "dial tcp: i/o timeout")
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/dead_jobs/ack_all", strings.NewReader("name=wat&error=timeout"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var ackRes struct {
+		Count int64 `json:"count"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &ackRes)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, ackRes.Count)
+
+	var res struct {
+		Jobs []struct {
+			ID    string `json:"id"`
+			Acked bool   `json:"acked"`
+		} `json:"jobs"`
+	}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	acked := map[string]bool{}
+	for _, j := range res.Jobs {
+		acked[j.ID] = j.Acked
+	}
+	assert.Equal(t, map[string]bool{"wat-1": true, "wat-2": true, "wat-3": false, "foo-4": false}, acked)
+
+	// The listing takes the same filters
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs?error=timeout&acked=false", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	res.Jobs = nil
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(res.Jobs)) {
+		assert.Equal(t, "foo-4", res.Jobs[0].ID)
+	}
+}
+
+func TestWebUIAnnotateDeadJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertDeadJobWithError(ns, pool, "wat", 0, 1, "dial tcp: i/o timeout")
+	insertDeadJobWithError(ns, pool, "wat", 0, 2, "dial tcp: i/o timeout")
+	insertDeadJobWithError(ns, pool, "wat", 0, 3, "ohno")
+	insertDeadJobWithError(ns, pool, "foo", 0, 4, "dial tcp: i/o timeout")
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/dead_jobs/annotate?error=timeout&status=unreviewed", strings.NewReader("status=known_issue&note=redis+failover"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var annotateRes struct {
+		Count int64 `json:"count"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &annotateRes)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, annotateRes.Count)
+
+	var res struct {
+		Jobs []struct {
+			ID         string `json:"id"`
+			Annotation *struct {
+				Status string `json:"status"`
+				Note   string `json:"note"`
+			} `json:"annotation"`
+		} `json:"jobs"`
+	}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs?status=known_issue", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	var ids []string
+	for _, j := range res.Jobs {
+		ids = append(ids, j.ID)
+		if assert.NotNil(t, j.Annotation) {
+			assert.Equal(t, "redis failover", j.Annotation.Note)
+		}
+	}
+	assert.Equal(t, []string{"wat-1", "wat-2", "foo-4"}, ids)
+
+	// A status is required
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/dead_jobs/annotate?name=wat", strings.NewReader("note=hmm"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.router.ServeHTTP(recorder, request)
+	assert.NotEqual(t, 200, recorder.Code)
+}
+
+func TestWebUIDeadJobsAnnotationStatus(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	j1 := insertDeadJob(ns, pool, "wat", 12345, 12347)
+	j2 := insertDeadJob(ns, pool, "wat", 12345, 12348)
+	insertDeadJob(ns, pool, "foo", 12345, 12349)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", fmt.Sprintf("/dead_job/%d/%s/annotate", 12347, j1.ID), strings.NewReader("status=investigating&note=hmm"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", fmt.Sprintf("/dead_job/%d/%s/annotate", 12348, j2.ID), strings.NewReader("status=known_issue"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		Count int64 `json:"count"`
+		Jobs  []struct {
+			ID         string `json:"id"`
+			Name       string `json:"name"`
+			Annotation *struct {
+				Status string `json:"status"`
+				Note   string `json:"note"`
+			} `json:"annotation"`
+		} `json:"jobs"`
+	}
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs?status=investigating", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.Count)
+	if assert.Equal(t, 1, len(res.Jobs)) {
+		assert.Equal(t, j1.ID, res.Jobs[0].ID)
+		assert.Equal(t, "investigating", res.Jobs[0].Annotation.Status)
+		assert.Equal(t, "hmm", res.Jobs[0].Annotation.Note)
+	}
+
+	res.Jobs = nil
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs?status=unreviewed", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.Count)
+	if assert.Equal(t, 1, len(res.Jobs)) {
+		assert.Equal(t, "foo", res.Jobs[0].Name)
+		assert.Nil(t, res.Jobs[0].Annotation)
+	}
+
+	res.Jobs = nil
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, res.Count)
+	assert.Equal(t, 3, len(res.Jobs))
+}
+
+func TestWebUIRetryAllDeadJobsFIFO(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	for _, failAt := range []int64{12349, 12347, 12352, 12348, 12351, 12350} {
+		insertDeadJobWithFails(ns, pool, "wat", failAt, 1)
+	}
+
+	s := NewServer(ns, pool, ":6666", "", "", WithRetryConcurrency(4))
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/retry_all_dead_jobs?max_fails=3&fifo=true", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.EqualValues(t, 6, listSize(pool, ns+":jobs:wat"))
+
+	// Workers pop from the right, so that's where the first job to die should be
+	conn := pool.Get()
+	defer conn.Close()
+	for failAt := int64(12347); failAt <= 12352; failAt++ {
+		rawJSON, err := redis.Bytes(conn.Do("RPOP", ns+":jobs:wat"))
+		assert.NoError(t, err)
+		var job work.Job
+		assert.NoError(t, json.Unmarshal(rawJSON, &job))
+		assert.Equal(t, fmt.Sprintf("wat-%d", failAt), job.ID)
+	}
+}
+
+func TestWebUIRetryDeadJobsMaxFails(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertDeadJobWithFails(ns, pool, "wat", 12347, 1)
+	insertDeadJobWithFails(ns, pool, "wat", 12348, 2)
+	insertDeadJobWithFails(ns, pool, "wat", 12349, 3)
+	insertDeadJobWithFails(ns, pool, "wat", 12350, 5)
+	hopeless := insertDeadJobWithFails(ns, pool, "wat", 12351, 4)
+	hopeful := insertDeadJobWithFails(ns, pool, "wat", 12352, 1)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	// Single job retries honor max_fails too
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", fmt.Sprintf("/retry_dead_job/%d/%s?max_fails=3", 12351, hopeless.ID), nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.Regexp(t, "skipped", recorder.Body.String())
+	assert.EqualValues(t, 0, listSize(pool, ns+":jobs:wat"))
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", fmt.Sprintf("/retry_dead_job/%d/%s?max_fails=3", 12352, hopeful.ID), nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:wat"))
+	assert.JSONEq(t, `{"status": "ok", "queue": "wat", "position": 1, "meta": {"namespace": "testwork"}}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/retry_all_dead_jobs?max_fails=3", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res struct {
+		Retried int64 `json:"retried"`
+		Skipped int64 `json:"skipped"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, res.Retried)
+	assert.EqualValues(t, 3, res.Skipped)
+	assert.EqualValues(t, 3, listSize(pool, ns+":jobs:wat"))
+
+	var deadRes struct {
+		Count int64 `json:"count"`
+		Jobs  []struct {
+			Fails int64 `json:"fails"`
+		} `json:"jobs"`
+	}
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &deadRes)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, deadRes.Count)
+	for _, j := range deadRes.Jobs {
+		assert.True(t, j.Fails >= 3)
+	}
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/retry_all_dead_jobs?max_fails=zero", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.NotEqual(t, 200, recorder.Code)
+}
+
+func TestWebUIWithoutUI(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	s := NewServer(ns, pool, ":6666", "admin", "secret", WithoutUI())
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/work.js", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/queues", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	// The root lists the API's endpoints instead
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	var res struct {
+		Endpoints []string `json:"endpoints"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Endpoints, "GET /queues")
+	assert.Contains(t, res.Endpoints, "POST /retry_dead_job/:died_at/:job_id")
+
+	// Or redirects, if configured to
+	s = NewServer(ns, pool, ":6666", "admin", "secret", WithoutUI(), WithRootRedirect("/queues"))
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/", nil)
+	request.SetBasicAuth("admin", "secret")
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 302, recorder.Code)
+	assert.Equal(t, "/queues", recorder.Header().Get("Location"))
+}
+
+func TestWebUIRoutes(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	s := NewServer(ns, pool, ":6666", "", "", WithoutUI())
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/routes", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+
+	var res []route
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Contains(t, res, route{Method: "GET", Path: "/queues"})
+	assert.Contains(t, res, route{Method: "GET", Path: "/routes"})
+	assert.Contains(t, res, route{Method: "POST", Path: "/retry_dead_job/:died_at/:job_id"})
+	assert.Contains(t, res, route{Method: "GET", Path: "/"})
+	assert.NotContains(t, res, route{Method: "GET", Path: "/work.js"})
+}
+
+func TestWebUIReadPool(t *testing.T) {
+	pool := newTestPool(":6379")
+	// Another database stands in for a replica, so we can tell which pool was used.
+	readPool := &redis.Pool{
+		MaxActive: 3,
+		MaxIdle:   3,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", ":6379", redis.DialDatabase(1))
+		},
+		Wait: true,
+	}
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+	cleanKeyspace(ns, readPool)
+
+	insertDeadJob(ns, pool, "primary", 1, 10)
+	insertDeadJob(ns, readPool, "replica", 1, 20)
+	insertDeadJob(ns, readPool, "replica", 1, 21)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithReadPool(readPool))
+
+	var res struct {
+		Count int64 `json:"count"`
+	}
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/dead_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, res.Count)
+
+	// Mutations go to the primary
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/delete_all_dead_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	assert.EqualValues(t, 0, zsetSize(pool, ns+":dead"))
+	assert.EqualValues(t, 2, zsetSize(readPool, ns+":dead"))
+
+	// Without a read pool, everything uses the primary
+	insertDeadJob(ns, pool, "primary", 1, 10)
+	s = NewServer(ns, pool, ":6666", "", "")
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/dead_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.Count)
+
+	cleanKeyspace(ns, readPool)
+}
+
+func TestWebUIUptime(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	var res struct {
+		StartedAt int64 `json:"started_at"`
+		Uptime    int64 `json:"uptime"`
+		Meta      struct {
+			Namespace string `json:"namespace"`
+		} `json:"meta"`
+	}
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/uptime", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err := json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1425263409, res.StartedAt)
+	assert.EqualValues(t, 0, res.Uptime)
+	assert.Equal(t, "testwork", res.Meta.Namespace)
+
+	setNowEpochSecondsMock(1425263409 + 30)
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/uptime", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 200, recorder.Code)
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1425263409, res.StartedAt)
+	assert.EqualValues(t, 30, res.Uptime)
+}
+
+func TestWebUIMeta(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code, path)
+		assert.Equal(t, "testwork", recorder.Header().Get("X-Work-Namespace"), path)
+		return recorder
+	}
+
+	// Object responses carry the namespace in their body too
+	for _, path := range []string{"/uptime", "/healthz", "/dead_jobs", "/retry_jobs"} {
+		var res struct {
+			Meta *meta `json:"meta"`
+		}
+		assert.NoError(t, json.Unmarshal(get(path).Body.Bytes(), &res), path)
+		if assert.NotNil(t, res.Meta, path) {
+			assert.Equal(t, "testwork", res.Meta.Namespace, path)
+		}
+	}
+
+	// Lists can't, so they only have the header
+	var queues []interface{}
+	assert.NoError(t, json.Unmarshal(get("/queues").Body.Bytes(), &queues))
+}
+
+func newTestPool(addr string) *redis.Pool {
+	return &redis.Pool{
+		MaxActive:   3,
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr)
+		},
+		Wait: true,
+	}
+}
+
+func insertDeadJob(ns string, pool *redis.Pool, name string, encAt, failAt int64) *work.Job {
+	return insertDeadJobWithError(ns, pool, name, encAt, failAt, "sorry")
+}
+
+func insertDeadJobWithError(ns string, pool *redis.Pool, name string, encAt, failAt int64, errStr string) *work.Job {
+	return insertDeadJobFull(ns, pool, &work.Job{
+		Name:       name,
+		ID:         fmt.Sprintf("%s-%d", name, failAt),
+		EnqueuedAt: encAt,
+		Fails:      3,
+		LastErr:    errStr,
+		FailedAt:   failAt,
+	})
+}
+
+func insertDeadJobWithFails(ns string, pool *redis.Pool, name string, failAt int64, fails int64) *work.Job {
+	return insertDeadJobFull(ns, pool, &work.Job{
+		Name:       name,
+		ID:         fmt.Sprintf("%s-%d", name, failAt),
+		EnqueuedAt: failAt - 10,
+		Fails:      fails,
+		LastErr:    "sorry",
+		FailedAt:   failAt,
+	})
+}
+
+func insertDeadJobFull(ns string, pool *redis.Pool, job *work.Job) *work.Job {
+	failAt, name := job.FailedAt, job.Name
+	rawJSON, _ := json.Marshal(job)
+
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("ZADD", ns+":dead", failAt, rawJSON); err != nil {
+		panic(err.Error())
+	}
+
+	if _, err := conn.Do("SADD", ns+":known_jobs", name); err != nil {
+		panic(err)
+	}
+
+	return job
+}
+
+func cleanKeyspace(namespace string, pool *redis.Pool) {
+	conn := pool.Get()
+	defer conn.Close()
+
+	keys, err := redis.Strings(conn.Do("KEYS", namespace+"*"))
+	if err != nil {
+		panic("could not get keys: " + err.Error())
+	}
+	for _, k := range keys {
+		if _, err := conn.Do("DEL", k); err != nil {
+			panic("could not del: " + err.Error())
+		}
+	}
+}
+
+func TestWebUINonObjectArgs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	for i, args := range []string{`[1,"two"]`, `"three"`, `{"four":4}`} {
+		rawJSON := fmt.Sprintf(`{"name":"wat","id":"job%d","t":1,"args":%s,"fails":1,"err":"ohno","failed_at":%d}`, i, args, 10+i)
+		_, err := conn.Do("ZADD", ns+":retry", 100+i, rawJSON)
+		assert.NoError(t, err)
+		_, err = conn.Do("ZADD", ns+":scheduled", 100+i, rawJSON)
+		assert.NoError(t, err)
+		_, err = conn.Do("ZADD", ns+":dead", 10+i, rawJSON)
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	for _, path := range []string{"/retry_jobs", "/scheduled_jobs", "/dead_jobs", "/failing_jobs"} {
+		var res struct {
+			Count int64 `json:"count"`
+			Jobs  []struct {
+				ID   string          `json:"id"`
+				Args json.RawMessage `json:"args"`
+			} `json:"jobs"`
+		}
+
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code, path)
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		args := map[string]string{}
+		for _, j := range res.Jobs {
+			var compact bytes.Buffer
+			assert.NoError(t, json.Compact(&compact, j.Args))
+			args[j.ID] = compact.String()
+		}
+		if path == "/failing_jobs" {
+			assert.Equal(t, 6, len(res.Jobs), path)
+		}
+		assert.Equal(t, map[string]string{"job0": `[1,"two"]`, "job1": `"three"`, "job2": `{"four":4}`}, args, path)
+	}
+}
+
+func zsetSize(pool *redis.Pool, key string) int64 {
+	conn := pool.Get()
+	defer conn.Close()
+
+	v, err := redis.Int64(conn.Do("ZCARD", key))
+	if err != nil {
+		panic("could not get zset size: " + err.Error())
+	}
+	return v
+}
+
+func TestWebUIReap(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	// crashed stopped heartbeating an hour ago, gone's heartbeat has expired, and alive is alive
+	_, err := conn.Do("SADD", ns+":worker_pools", "crashed", "gone", "alive")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:crashed", "heartbeat_at", time.Now().Add(-time.Hour).Unix(), "worker_ids", "w1")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:alive", "heartbeat_at", time.Now().Unix(), "worker_ids", "w2")
+	assert.NoError(t, err)
+	for _, wid := range []string{"w1", "w2"} {
+		_, err = conn.Do("HMSET", ns+":worker:"+wid, "job_name", "wat", "job_id", "job-"+wid, "started_at", time.Now().Add(-time.Hour).Unix())
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	// It's only served when enabled
+	s := NewServer(ns, pool, ":6666", "", "")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/reap", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+
+	s = NewServer(ns, pool, ":6666", "", "", WithReap())
+	type reaped struct {
+		WorkerObservations int64 `json:"worker_observations"`
+		WorkerPools        int64 `json:"worker_pools"`
+	}
+	reap := func() reaped {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/reap", nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 200, recorder.Code)
+
+		var res reaped
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		return res
+	}
+
+	assert.Equal(t, reaped{WorkerObservations: 1, WorkerPools: 1}, reap())
+
+	conn = pool.Get()
+	defer conn.Close()
+	poolIDs, err := redis.Strings(conn.Do("SMEMBERS", ns+":worker_pools"))
+	assert.NoError(t, err)
+	sort.Strings(poolIDs)
+	// crashed is left for the dead pool reaper, which requeues its in-progress jobs
+	assert.Equal(t, []string{"alive", "crashed"}, poolIDs)
+	exists, err := redis.Bool(conn.Do("EXISTS", ns+":worker:w2"))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.Equal(t, reaped{}, reap())
+}
+
+func TestWebUIRequeueInFlight(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	cleanKeyspace(ns, pool)
+
+	conn := pool.Get()
+	_, err := conn.Do("SADD", ns+":worker_pools", "crashed", "alive")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:crashed", "heartbeat_at", time.Now().Add(-time.Hour).Unix(), "job_names", "wat")
+	assert.NoError(t, err)
+	_, err = conn.Do("HMSET", ns+":worker_pools:alive", "heartbeat_at", time.Now().Unix(), "job_names", "wat")
+	assert.NoError(t, err)
+	for _, poolID := range []string{"crashed", "crashed", "alive"} {
+		_, err = conn.Do("LPUSH", ns+":jobs:wat:"+poolID+":inprogress", `{"name":"wat","id":"a"}`)
+		assert.NoError(t, err)
+	}
+	conn.Close()
+
+	// It's off by default
+	s := NewServer(ns, pool, ":6666", "", "")
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/worker_pool/crashed/requeue_inflight", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 404, recorder.Code)
+	assert.EqualValues(t, 2, listSize(pool, ns+":jobs:wat:crashed:inprogress"))
+
+	s = NewServer(ns, pool, ":6666", "", "", WithRequeueInFlight())
+
+	requeue := func(poolID string) (int, int64) {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("POST", "/worker_pool/"+poolID+"/requeue_inflight", nil)
+		s.router.ServeHTTP(recorder, request)
+
+		var res struct {
+			Count int64 `json:"count"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		return recorder.Code, res.Count
+	}
+
+	code, _ := requeue("alive")
+	assert.Equal(t, 409, code)
+	assert.EqualValues(t, 1, listSize(pool, ns+":jobs:wat:alive:inprogress"))
+
+	code, count := requeue("crashed")
+	assert.Equal(t, 200, code)
+	assert.EqualValues(t, 2, count)
+	assert.EqualValues(t, 0, listSize(pool, ns+":jobs:wat:crashed:inprogress"))
+	assert.EqualValues(t, 2, listSize(pool, ns+":jobs:wat"))
+}
+
+func TestWebUIMostRetriedJobs(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	insertRetryJobWithFails(ns, pool, "wat", 100, 10, 1)
+	insertRetryJobWithFails(ns, pool, "wat", 101, 11, 7)
+	insertRetryJobWithFails(ns, pool, "foo", 102, 12, 3)
+	insertRetryJobWithFails(ns, pool, "foo", 103, 13, 12)
+	insertRetryJobWithFails(ns, pool, "bar", 104, 14, 3)
+
+	s := NewServer(ns, pool, ":6666", "", "", WithMaxPageSize(50))
+
+	get := func(query string) (int, []string) {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/retry_jobs/most_retried?"+query, nil)
+		s.router.ServeHTTP(recorder, request)
+
+		var res struct {
+			Count int64 `json:"count"`
+			Jobs  []struct {
+				Name    string `json:"name"`
+				ID      string `json:"id"`
+				Fails   int64  `json:"fails"`
+				LastErr string `json:"err"`
+			} `json:"jobs"`
+		}
+		ranking := []string{}
+		if recorder.Code == 200 {
+			err := json.Unmarshal(recorder.Body.Bytes(), &res)
+			assert.NoError(t, err)
+			assert.EqualValues(t, len(res.Jobs), res.Count)
+			for _, j := range res.Jobs {
+				assert.Equal(t, "sorry", j.LastErr)
+				ranking = append(ranking, fmt.Sprintf("%s:%d", j.ID, j.Fails))
+			}
+		}
+		return recorder.Code, ranking
+	}
+
+	// Ties keep their order in the retry set
+	code, ranking := get("")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{"foo-13:12", "wat-11:7", "foo-12:3", "bar-14:3", "wat-10:1"}, ranking)
+
+	code, ranking = get("limit=2")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{"foo-13:12", "wat-11:7"}, ranking)
+
+	code, _ = get("limit=0")
+	assert.Equal(t, 400, code)
+	code, _ = get("limit=51")
+	assert.Equal(t, 400, code)
+}
+
+func TestRenderErrorEscaping(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	renderError(recorder, fmt.Errorf("bad \"quote\" and back\\slash\nnext line"))
+	assert.Equal(t, 500, recorder.Code)
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	var res struct {
+		Error string `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Equal(t, "bad \"quote\" and back\\slash\nnext line", res.Error)
+
+	recorder = httptest.NewRecorder()
+	renderError(recorder, badRequestError(`page must be "1" or more`))
+	assert.Equal(t, 400, recorder.Code)
+	assert.JSONEq(t, `{"error": "page must be \"1\" or more"}`, recorder.Body.String())
+}
+
+func TestWebUIClientErrorStatus(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/retry_jobs?page=abc", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 400, recorder.Code)
+
+	// A retry set of the wrong type makes the client fail, which is the server's problem
+	conn := pool.Get()
+	_, err := conn.Do("SET", ns+":retry", "wat")
+	assert.NoError(t, err)
+	conn.Close()
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/retry_jobs", nil)
+	s.router.ServeHTTP(recorder, request)
+	assert.Equal(t, 500, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "WRONGTYPE")
+
+	recorder = httptest.NewRecorder()
+	renderErrorCode(recorder, http.StatusConflict, fmt.Errorf("already \"done\""))
+	assert.Equal(t, 409, recorder.Code)
+	assert.JSONEq(t, `{"error": "already \"done\""}`, recorder.Body.String())
+}
+
+func TestWebUIBadParams(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "testwork"
+	cleanKeyspace(ns, pool)
+
+	s := NewServer(ns, pool, ":6666", "", "")
+
+	cases := []struct {
+		method, path string
+		error        string
+	}{
+		{"GET", "/retry_jobs?page=nope", `invalid page "nope"`},
+		{"GET", "/dead_jobs?page_size=99999999999999999999", `page_size "99999999999999999999" is out of range`},
+		{"POST", "/delete_dead_job/12x/abc", `invalid died_at "12x"`},
+		{"POST", "/retry_dead_job/12x/abc", `invalid died_at "12x"`},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest(tc.method, tc.path, nil)
+		s.router.ServeHTTP(recorder, request)
+		assert.Equal(t, 400, recorder.Code, tc.path)
+
+		var res struct {
+			Error string `json:"error"`
 		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		assert.Equal(t, tc.error, res.Error, tc.path)
 	}
 }
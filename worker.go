@@ -63,7 +63,7 @@ func (w *worker) updateMiddlewareAndJobTypes(middleware []*middlewareHandler, jo
 	}
 	w.sampler = sampler
 	w.jobTypes = jobTypes
-	w.redisFetchScript = redis.NewScript(len(jobTypes)*2, redisLuaRpoplpushMultiCmd)
+	w.redisFetchScript = redis.NewScript(len(jobTypes)*2+3, redisLuaFetchJobCmd)
 }
 
 func (w *worker) start() {
@@ -136,10 +136,12 @@ func (w *worker) fetchJob() (*Job, error) {
 	// NOTE: we could optimize this to only resort every second, or something.
 	w.sampler.sample()
 
-	var scriptArgs = make([]interface{}, 0, len(w.sampler.samples)*2)
+	var scriptArgs = make([]interface{}, 0, len(w.sampler.samples)*2+4)
+	scriptArgs = append(scriptArgs, redisKeyProcessed(w.namespace), redisKeyRateLimit(w.namespace), redisKeyRateLimitCount(w.namespace, nowEpochSeconds()))
 	for _, s := range w.sampler.samples {
 		scriptArgs = append(scriptArgs, s.redisJobs, s.redisJobsInProg)
 	}
+	scriptArgs = append(scriptArgs, redisKeyJobsPrefix(w.namespace))
 
 	conn := w.pool.Get()
 	defer conn.Close()
@@ -201,6 +203,16 @@ func (w *worker) processJob(job *Job) {
 	}
 }
 
+func (w *worker) countFailed(job *Job) {
+	conn := w.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("HINCRBY", redisKeyFailed(w.namespace), job.Name, 1)
+	if err != nil {
+		logError("worker.count_failed.hincrby", err)
+	}
+}
+
 func (w *worker) deleteUniqueJob(job *Job) {
 	uniqueKey, err := redisKeyUniqueJob(w.namespace, job.Name, job.Args)
 	if err != nil {
@@ -232,6 +244,9 @@ func (w *worker) addToRetryOrDead(jt *jobType, job *Job, runErr error) {
 	} else {
 		if !jt.SkipDead {
 			w.addToDead(job, runErr)
+		} else {
+			// Nothing else is written for a job that's dropped, so its failure is counted on its own
+			w.countFailed(job)
 		}
 	}
 }
@@ -260,6 +275,7 @@ func (w *worker) addToRetry(job *Job, runErr error) {
 	conn.Send("MULTI")
 	conn.Send("LREM", job.inProgQueue, 1, job.rawJSON)
 	conn.Send("ZADD", redisKeyRetry(w.namespace), nowEpochSeconds()+backoff(job), rawJSON)
+	conn.Send("HINCRBY", redisKeyFailed(w.namespace), job.Name, 1)
 	_, err = conn.Do("EXEC")
 	if err != nil {
 		logError("worker.add_to_retry.exec", err)
@@ -286,6 +302,7 @@ func (w *worker) addToDead(job *Job, runErr error) {
 	conn.Send("MULTI")
 	conn.Send("LREM", job.inProgQueue, 1, job.rawJSON)
 	conn.Send("ZADD", redisKeyDead(w.namespace), nowEpochSeconds(), rawJSON)
+	conn.Send("HINCRBY", redisKeyFailed(w.namespace), job.Name, 1)
 	_, err = conn.Do("EXEC")
 	if err != nil {
 		logError("worker.add_to_dead.exec", err)
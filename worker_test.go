@@ -287,6 +287,56 @@ func TestWorkerDead(t *testing.T) {
 
 // Test that in the case of an unavailable Redis server,
 // the worker loop exits in the case of a WorkerPool.Stop
+func TestWorkerRateLimit(t *testing.T) {
+	pool := newTestPool(":6379")
+	ns := "work"
+	job1 := "job1"
+	cleanKeyspace(ns, pool)
+
+	setNowEpochSecondsMock(1425263409)
+	defer resetNowEpochSecondsMock()
+
+	jobTypes := map[string]*jobType{
+		job1: {
+			Name:           job1,
+			JobOptions:     JobOptions{Priority: 1},
+			IsGeneric:      true,
+			GenericHandler: func(job *Job) error { return nil },
+		},
+	}
+
+	enqueuer := NewEnqueuer(ns, pool)
+	for i := 0; i < 5; i++ {
+		_, err := enqueuer.Enqueue(job1, nil)
+		assert.NoError(t, err)
+	}
+
+	client := NewClient(ns, pool)
+	assert.NoError(t, client.SetRateLimit(2))
+
+	w := newWorker(ns, "1", pool, tstCtxType, nil, jobTypes)
+	fetch := func() *Job {
+		job, err := w.fetchJob()
+		assert.NoError(t, err)
+		return job
+	}
+
+	// Two jobs a second, after which the queue looks empty until the next second
+	assert.NotNil(t, fetch())
+	assert.NotNil(t, fetch())
+	assert.Nil(t, fetch())
+	assert.EqualValues(t, 3, listSize(pool, redisKeyJobs(ns, job1)))
+
+	setNowEpochSecondsMock(1425263410)
+	assert.NotNil(t, fetch())
+
+	// Without a limit, the rest are fetched right away
+	assert.NoError(t, client.SetRateLimit(0))
+	assert.NotNil(t, fetch())
+	assert.NotNil(t, fetch())
+	assert.EqualValues(t, 0, listSize(pool, redisKeyJobs(ns, job1)))
+}
+
 func TestStop(t *testing.T) {
 	redisPool := &redis.Pool{
 		Dial: func() (redis.Conn, error) {